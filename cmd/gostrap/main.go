@@ -0,0 +1,51 @@
+// gostrap 命令行工具
+//
+//	gostrap config dump|validate|schema ...
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/wufashanchu/gostrap/pkg/conf"
+	"github.com/wufashanchu/gostrap/pkg/conf/cli"
+	"github.com/wufashanchu/gostrap/pkg/log"
+	"github.com/wufashanchu/gostrap/pkg/observability/metrics"
+	"github.com/wufashanchu/gostrap/pkg/observability/tracing"
+)
+
+// Config gostrap 内置组件的配置
+type Config struct {
+	Log     log.Config     `mapstructure:"log"`
+	Tracing tracing.Config `mapstructure:"tracing"`
+	Metrics metrics.Config `mapstructure:"metrics"`
+}
+
+func init() {
+	conf.Register("gostrap", &Config{})
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "config":
+		os.Exit(cli.Run(os.Args[2:], os.Stdout, os.Stderr))
+	case "-h", "-help", "--help", "help":
+		usage()
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `Usage: gostrap <command> [arguments]
+
+Commands:
+  config   inspect, validate and describe configuration`)
+}
@@ -0,0 +1,230 @@
+// Package cli 实现 gostrap config 子命令，服务也可以在自己的命令行中复用
+//
+//	gostrap config dump [flags] <file>      输出最终生效的配置(敏感值已脱敏)
+//	gostrap config validate [flags] <file>  按 validate 标签校验配置，失败时退出码为 1
+//	gostrap config schema [-type name]      输出已注册配置结构体的 JSON Schema
+//
+// 配置结构体需先通过 conf.Register 注册，只注册了一个类型时 -type 可省略。
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/wufashanchu/gostrap/pkg/conf"
+)
+
+// Run 执行 config 子命令，args 不包含 "config" 本身，返回进程退出码
+func Run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+
+	var err error
+	switch args[0] {
+	case "dump":
+		err = runDump(args[1:], stdout, stderr)
+	case "validate":
+		err = runValidate(args[1:], stdout, stderr)
+	case "schema":
+		err = runSchema(args[1:], stdout, stderr)
+	case "-h", "-help", "--help", "help":
+		usage(stdout)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown config command %q\n", args[0])
+		usage(stderr)
+		return 2
+	}
+
+	var ve *conf.ValidationError
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.As(err, &ve):
+		for _, f := range ve.Fields {
+			fmt.Fprintf(stderr, "%s: %s\n", f.Key, f.Message)
+		}
+		return 1
+	case errors.Is(err, errUsage):
+		return 2
+	default:
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+}
+
+var errUsage = errors.New("usage error")
+
+func usage(w io.Writer) {
+	fmt.Fprint(w, `Usage: gostrap config <command> [flags]
+
+Commands:
+  dump [flags] <file>      print the effective configuration with secrets redacted
+  validate [flags] <file>  validate the configuration against the registered struct
+  schema [-type name]      print the JSON Schema of the registered struct
+
+Registered types: `+strings.Join(conf.Registered(), ", ")+"\n")
+}
+
+// loadFlags dump 与 validate 共用的加载参数
+type loadFlags struct {
+	typeName  string
+	profile   string
+	envPrefix string
+	overrides stringList
+}
+
+func (f *loadFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.typeName, "type", "", "registered config type")
+	fs.StringVar(&f.profile, "profile", "", "profile overlay, e.g. prod loads config.prod.yaml")
+	fs.StringVar(&f.envPrefix, "env-prefix", conf.DefaultEnvPrefix, "environment variable prefix")
+	fs.Var(&f.overrides, "override", "optional override file (repeatable)")
+}
+
+func (f *loadFlags) options() []conf.Option {
+	opts := []conf.Option{conf.WithEnvPrefix(f.envPrefix)}
+	if f.profile != "" {
+		opts = append(opts, conf.WithProfile(f.profile))
+	}
+	if len(f.overrides) > 0 {
+		opts = append(opts, conf.WithOverrideFile(f.overrides...))
+	}
+	return opts
+}
+
+func runDump(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("dump", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var lf loadFlags
+	lf.register(fs)
+	format := fs.String("o", "yaml", "output format: yaml or json")
+	origins := fs.Bool("origins", false, "print where each key comes from instead of values")
+
+	file, err := parseFileArg(fs, args)
+	if err != nil {
+		return err
+	}
+	obj, err := newConfig(lf.typeName)
+	if err != nil {
+		return err
+	}
+
+	values, o, err := conf.Dump(file, obj, lf.options()...)
+	if err != nil {
+		return err
+	}
+	if *origins {
+		_, err = io.WriteString(stdout, o.String())
+		return err
+	}
+	return encode(stdout, *format, values)
+}
+
+func runValidate(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var lf loadFlags
+	lf.register(fs)
+
+	file, err := parseFileArg(fs, args)
+	if err != nil {
+		return err
+	}
+	obj, err := newConfig(lf.typeName)
+	if err != nil {
+		return err
+	}
+
+	if _, err := conf.Load(file, obj, lf.options()...); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "%s: ok\n", file)
+	return nil
+}
+
+func runSchema(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("schema", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	typeName := fs.String("type", "", "registered config type")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	obj, err := newConfig(*typeName)
+	if err != nil {
+		return err
+	}
+	return encode(stdout, "json", conf.JSONSchema(obj))
+}
+
+// parseFileArg 解析参数并返回唯一的配置文件路径
+func parseFileArg(fs *flag.FlagSet, args []string) (string, error) {
+	if err := fs.Parse(args); err != nil {
+		return "", err
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintf(fs.Output(), "usage: gostrap config %s [flags] <file>\n", fs.Name())
+		fs.PrintDefaults()
+		return "", errUsage
+	}
+	return fs.Arg(0), nil
+}
+
+// newConfig 创建已注册配置类型的实例
+func newConfig(name string) (any, error) {
+	names := conf.Registered()
+	if name == "" {
+		switch len(names) {
+		case 0:
+			return nil, errors.New("no config type registered, call conf.Register first")
+		case 1:
+			name = names[0]
+		default:
+			return nil, fmt.Errorf("multiple config types registered, choose one with -type: %s", strings.Join(names, ", "))
+		}
+	}
+	obj, ok := conf.NewRegistered(name)
+	if !ok {
+		return nil, fmt.Errorf("config type %q is not registered (registered: %s)", name, strings.Join(names, ", "))
+	}
+	return obj, nil
+}
+
+func encode(w io.Writer, format string, v any) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "yaml", "yml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	default:
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+}
+
+// stringList 可重复的字符串参数
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
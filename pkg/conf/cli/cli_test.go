@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wufashanchu/gostrap/pkg/conf"
+)
+
+type cliConfig struct {
+	Name     string `mapstructure:"name" validate:"required"`
+	Password string `mapstructure:"password" secret:"true"`
+}
+
+func init() {
+	conf.Register("cli-test", cliConfig{})
+}
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestRun(t *testing.T) {
+	valid := writeConfig(t, "name: orders\npassword: hunter2\n")
+	invalid := writeConfig(t, "password: hunter2\n")
+
+	tests := []struct {
+		name   string
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{name: "dump", args: []string{"dump", valid}, code: 0, stdout: "password: '" + conf.Redacted + "'"},
+		{name: "dump json", args: []string{"dump", "-o", "json", valid}, code: 0, stdout: `"name": "orders"`},
+		{name: "validate ok", args: []string{"validate", valid}, code: 0, stdout: "ok"},
+		{name: "validate fails", args: []string{"validate", invalid}, code: 1, stderr: "name: is required"},
+		{name: "schema", args: []string{"schema"}, code: 0, stdout: `"writeOnly": true`},
+		{name: "missing file", args: []string{"dump"}, code: 2},
+		{name: "unknown command", args: []string{"nope"}, code: 2, stderr: "unknown config command"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := Run(tt.args, &stdout, &stderr); code != tt.code {
+				t.Fatalf("Run() = %d, want %d\nstderr: %s", code, tt.code, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.stdout) {
+				t.Errorf("stdout = %q, want it to contain %q", stdout.String(), tt.stdout)
+			}
+			if !strings.Contains(stderr.String(), tt.stderr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr.String(), tt.stderr)
+			}
+			if strings.Contains(stdout.String(), "hunter2") {
+				t.Errorf("stdout contains the secret: %s", stdout.String())
+			}
+		})
+	}
+}
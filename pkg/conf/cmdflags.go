@@ -0,0 +1,119 @@
+package conf
+
+import (
+	"flag"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// OriginFlag 由命令行参数提供的配置来源
+const OriginFlag = "flag"
+
+// AddFlags 为 obj 的每个叶子字段在 fs 中注册同名命令行参数，如 Log.Level 对应 --log.level
+//
+// 参数说明取自 desc 标签。支持布尔、字符串、整数、浮点数、time.Duration 与 []string 字段，
+// 其他类型(如 map)的字段不生成参数。生成的参数没有默认值，未指定时由更低优先级的配置层决定。
+// fs 需通过 WithFlags 传给 Load 后才会生效。
+func AddFlags(fs *pflag.FlagSet, obj any) {
+	t := reflect.TypeOf(obj)
+	if t == nil {
+		return
+	}
+	walkFields(t, "", func(key string, f reflect.StructField) {
+		if key == "" || fs.Lookup(key) != nil {
+			return
+		}
+		usage := f.Tag.Get(descriptionTag)
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		switch {
+		case ft == durationType:
+			fs.Duration(key, 0, usage)
+		case ft.Kind() == reflect.Bool:
+			fs.Bool(key, false, usage)
+		case ft.Kind() == reflect.String:
+			fs.String(key, "", usage)
+		case ft.Kind() >= reflect.Int && ft.Kind() <= reflect.Int64:
+			fs.Int64(key, 0, usage)
+		case ft.Kind() >= reflect.Uint && ft.Kind() <= reflect.Uint64:
+			fs.Uint64(key, 0, usage)
+		case ft.Kind() == reflect.Float32 || ft.Kind() == reflect.Float64:
+			fs.Float64(key, 0, usage)
+		case ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.String:
+			fs.StringSlice(key, nil, usage)
+		}
+	})
+}
+
+// AddGoFlags 与 AddFlags 相同，参数注册到标准库 flag.FlagSet 中，需通过 WithGoFlags 生效
+func AddGoFlags(fs *flag.FlagSet, obj any) {
+	pfs := pflag.NewFlagSet(fs.Name(), pflag.ContinueOnError)
+	AddFlags(pfs, obj)
+	pfs.CopyToGoFlagSet(fs)
+}
+
+// WithFlags 使用已解析的命令行参数覆盖配置，只有显式指定且名称与配置键相同的参数才会生效
+//
+// 优先级从高到低：命令行参数 > 环境变量 > 配置文件与配置源 > default 标签。
+func WithFlags(fs *pflag.FlagSet) Option {
+	return func(o *options) {
+		if fs != nil {
+			o.flagSets = append(o.flagSets, fs)
+		}
+	}
+}
+
+// WithGoFlags 与 WithFlags 相同，用于标准库 flag.FlagSet
+func WithGoFlags(fs *flag.FlagSet) Option {
+	return func(o *options) {
+		if fs != nil {
+			o.goFlagSets = append(o.goFlagSets, fs)
+		}
+	}
+}
+
+// fromGoFlags 将标准库 flag.FlagSet 转换为 pflag.FlagSet，并保留参数是否被显式指定
+func fromGoFlags(fs *flag.FlagSet) *pflag.FlagSet {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	pfs := pflag.NewFlagSet(fs.Name(), pflag.ContinueOnError)
+	fs.VisitAll(func(f *flag.Flag) {
+		pf := pflag.PFlagFromGoFlag(f)
+		pf.Changed = set[f.Name]
+		pfs.AddFlag(pf)
+	})
+	return pfs
+}
+
+// bindFlags 将显式指定的命令行参数绑定到同名配置键，并记录其来源
+func bindFlags(v *viper.Viper, origins Origins, o *options) {
+	flagSets := slices.Clone(o.flagSets)
+	for _, fs := range o.goFlagSets {
+		flagSets = append(flagSets, fromGoFlags(fs))
+	}
+	if len(flagSets) == 0 {
+		return
+	}
+	keys := make(map[string]bool)
+	for _, key := range v.AllKeys() {
+		keys[key] = true
+	}
+	for _, fs := range flagSets {
+		// 由 fromGoFlags 转换的参数集合只设置了 Changed，Visit 无法遍历到，因此使用 VisitAll
+		fs.VisitAll(func(f *pflag.Flag) {
+			key := strings.ToLower(f.Name)
+			if !f.Changed || !keys[key] {
+				return
+			}
+			_ = v.BindPFlag(key, f)
+			origins[key] = OriginFlag + ":--" + f.Name
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// secretTag 标记敏感字段，其值在变更记录中会被脱敏
+const secretTag = "secret"
+
+// Redacted 敏感值脱敏后的占位符
+const Redacted = "******"
+
+// Change 单个配置键的变化
+type Change struct {
+	Key string
+	Old any // 新增的键为 nil
+	New any // 删除的键为 nil
+}
+
+// Diff 两次配置之间的差异，键为点分隔路径，map 类型的字段会展开到每个元素
+type Diff struct {
+	Added   []Change
+	Removed []Change
+	Changed []Change
+}
+
+// Empty 是否没有任何变化
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Keys 返回所有发生变化的键(有序)
+func (d Diff) Keys() []string {
+	keys := make([]string, 0, len(d.Added)+len(d.Removed)+len(d.Changed))
+	for _, list := range [][]Change{d.Added, d.Removed, d.Changed} {
+		for _, c := range list {
+			keys = append(keys, c.Key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Has 判断是否有键匹配任一模式，模式为完整键路径或以 .* 结尾的前缀，如 database.*
+func (d Diff) Has(patterns ...string) bool {
+	for _, key := range d.Keys() {
+		for _, p := range patterns {
+			if matchKey(strings.ToLower(p), key) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (d Diff) String() string {
+	var b strings.Builder
+	for _, c := range d.Added {
+		fmt.Fprintf(&b, "+ %s = %v\n", c.Key, c.New)
+	}
+	for _, c := range d.Removed {
+		fmt.Fprintf(&b, "- %s = %v\n", c.Key, c.Old)
+	}
+	for _, c := range d.Changed {
+		fmt.Fprintf(&b, "~ %s: %v -> %v\n", c.Key, c.Old, c.New)
+	}
+	return b.String()
+}
+
+func matchKey(pattern, key string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, ".*"); ok {
+		return key == prefix || strings.HasPrefix(key, prefix+".")
+	}
+	return key == pattern
+}
+
+// WithOnChange 注册接收变更内容的重新加载回调，订阅者可据此只重建受影响的部分
+func WithOnChange(fns ...func(Diff)) Option {
+	return func(o *options) {
+		o.onChange = append(o.onChange, fns...)
+	}
+}
+
+// Compare 比较两个同类型配置结构体，带 secret:"true" 标签的字段值会被脱敏，标签在结构体字段上时其中的所有字段都会被脱敏
+func Compare(old, new any) Diff {
+	return compare(old, new, nil)
+}
+
+// compare 比较配置，secrets 中的键(如由密钥引用解析得到的值)同样脱敏
+func compare(old, new any, secrets map[string]bool) Diff {
+	oldValues, oldSecrets := flatten(old)
+	newValues, newSecrets := flatten(new)
+	secret := func(key string) bool { return oldSecrets[key] || newSecrets[key] || isSecretKey(secrets, key) }
+	redact := func(key string, v any) any {
+		if v != nil && secret(key) {
+			return Redacted
+		}
+		return v
+	}
+
+	var d Diff
+	for key, nv := range newValues {
+		ov, ok := oldValues[key]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, Change{Key: key, New: redact(key, nv)})
+		case !reflect.DeepEqual(ov, nv):
+			d.Changed = append(d.Changed, Change{Key: key, Old: redact(key, ov), New: redact(key, nv)})
+		}
+	}
+	for key, ov := range oldValues {
+		if _, ok := newValues[key]; !ok {
+			d.Removed = append(d.Removed, Change{Key: key, Old: redact(key, ov)})
+		}
+	}
+	for _, list := range [][]Change{d.Added, d.Removed, d.Changed} {
+		sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	}
+	return d
+}
+
+// flatten 将结构体展开为 键路径 -> 值，并返回敏感键集合
+func flatten(obj any) (map[string]any, map[string]bool) {
+	values := make(map[string]any)
+	secrets := make(map[string]bool)
+	walkValues(reflect.ValueOf(obj), "", func(key string, path []reflect.StructField, fv reflect.Value) {
+		// secret 标签标记在结构体字段上时，其中的所有字段都视为敏感
+		isSecret := slices.ContainsFunc(path, func(f reflect.StructField) bool { return f.Tag.Get(secretTag) == "true" })
+		flattenValue(key, fv, isSecret, values, secrets)
+	})
+	return values, secrets
+}
+
+// flattenValue 展开以字符串为键的 map，其他值作为叶子记录
+func flattenValue(key string, v reflect.Value, secret bool, values map[string]any, secrets map[string]bool) {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			values[key] = nil
+			secrets[key] = secret
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String {
+		iter := v.MapRange()
+		for iter.Next() {
+			flattenValue(joinKey(key, strings.ToLower(iter.Key().String())), iter.Value(), secret, values, secrets)
+		}
+		return
+	}
+	values[key] = v.Interface()
+	secrets[key] = secret
+}
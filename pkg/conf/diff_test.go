@@ -0,0 +1,73 @@
+package conf
+
+import (
+	"strings"
+	"testing"
+)
+
+type diffCredentials struct {
+	User     string `mapstructure:"user"`
+	Password string `mapstructure:"password"`
+}
+
+type diffConfig struct {
+	Name   string            `mapstructure:"name"`
+	Token  string            `mapstructure:"token" secret:"true"`
+	DB     diffCredentials   `mapstructure:"db" secret:"true"`
+	Labels map[string]string `mapstructure:"labels"`
+}
+
+func TestCompare(t *testing.T) {
+	old := diffConfig{
+		Name:   "a",
+		Token:  "old-token",
+		DB:     diffCredentials{User: "root", Password: "old-pass"},
+		Labels: map[string]string{"env": "dev", "team": "core"},
+	}
+	new := diffConfig{
+		Name:   "b",
+		Token:  "new-token",
+		DB:     diffCredentials{User: "root", Password: "new-pass"},
+		Labels: map[string]string{"env": "prod", "zone": "eu"},
+	}
+	d := Compare(&old, &new)
+
+	if got, want := strings.Join(d.Keys(), ","), "db.password,labels.env,labels.team,labels.zone,name,token"; got != want {
+		t.Fatalf("Keys() = %s, want %s", got, want)
+	}
+	if len(d.Added) != 1 || d.Added[0].Key != "labels.zone" || d.Added[0].New != "eu" {
+		t.Errorf("Added = %+v", d.Added)
+	}
+	if len(d.Removed) != 1 || d.Removed[0].Key != "labels.team" || d.Removed[0].Old != "core" {
+		t.Errorf("Removed = %+v", d.Removed)
+	}
+	for _, c := range d.Changed {
+		secret := c.Key == "token" || c.Key == "db.password"
+		if secret && (c.Old != Redacted || c.New != Redacted) {
+			t.Errorf("%s: %v -> %v, want redacted", c.Key, c.Old, c.New)
+		}
+		if !secret && (c.Old == Redacted || c.New == Redacted) {
+			t.Errorf("%s redacted, want plain values", c.Key)
+		}
+	}
+	for _, s := range []string{"old-token", "new-token", "old-pass", "new-pass"} {
+		if strings.Contains(d.String(), s) {
+			t.Errorf("String() contains %q:\n%s", s, d)
+		}
+	}
+	if !d.Has("labels.*") || !d.Has("DB.Password") || d.Has("db.user") {
+		t.Errorf("Has() results wrong for %v", d.Keys())
+	}
+}
+
+func TestCompareResolvedSecrets(t *testing.T) {
+	old := diffConfig{Name: "a"}
+	new := diffConfig{Name: "from-env"}
+	d := compare(&old, &new, map[string]bool{"name": true})
+	if len(d.Changed) != 1 || d.Changed[0].New != Redacted {
+		t.Errorf("Changed = %+v, want name redacted", d.Changed)
+	}
+	if !Compare(&old, &old).Empty() {
+		t.Error("Compare of equal configs is not empty")
+	}
+}
@@ -0,0 +1,51 @@
+package conf
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Dump 按 Load 的规则加载配置并返回最终生效的配置(嵌套 map)及每个键的来源
+//
+// 敏感值(secret 标签字段以及由密钥引用解析得到的值)会被替换为 Redacted。
+// Dump 不做校验，也不监听配置变化，适合用于排查线上配置。
+func Dump(configFile string, obj any, opts ...Option) (map[string]any, Origins, error) {
+	l, err := load(configFile, obj, newOptions(opts))
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := l.v.Unmarshal(obj); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal configs: %w", err)
+	}
+
+	values, secrets := flatten(obj)
+	out := make(map[string]any)
+	for key, val := range values {
+		switch {
+		case val == nil:
+		case secrets[key] || isSecretKey(l.secrets, key):
+			val = Redacted
+		default:
+			if d, ok := val.(time.Duration); ok {
+				val = d.String()
+			}
+		}
+		setNested(out, key, val)
+	}
+	return out, l.origins, nil
+}
+
+// setNested 按点分隔路径写入嵌套 map
+func setNested(m map[string]any, key string, val any) {
+	parts := strings.Split(key, ".")
+	for _, p := range parts[:len(parts)-1] {
+		next, ok := m[p].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			m[p] = next
+		}
+		m = next
+	}
+	m[parts[len(parts)-1]] = val
+}
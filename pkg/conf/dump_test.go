@@ -0,0 +1,37 @@
+package conf
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type dumpConfig struct {
+	Name     string          `mapstructure:"name"`
+	Password string          `mapstructure:"password" secret:"true"`
+	APIKey   string          `mapstructure:"api_key"`
+	DB       diffCredentials `mapstructure:"db" secret:"true"`
+}
+
+func TestDumpRedactsSecrets(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, file, "name: orders\npassword: hunter2\napi_key: ${env:TEST_DUMP_KEY}\ndb:\n  user: root\n  password: dbpass\n")
+	t.Setenv("TEST_DUMP_KEY", "sk-123")
+
+	values, origins, err := Dump(file, &dumpConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["name"] != "orders" {
+		t.Errorf("name = %v, want orders", values["name"])
+	}
+	db, _ := values["db"].(map[string]any)
+	for key, got := range map[string]any{"password": values["password"], "api_key": values["api_key"], "db.user": db["user"], "db.password": db["password"]} {
+		if got != Redacted {
+			t.Errorf("%s = %v, want %s", key, got, Redacted)
+		}
+	}
+	if got := origins.Of("name"); !strings.HasPrefix(got, OriginFile) {
+		t.Errorf("Origins.Of(name) = %q, want a file origin", got)
+	}
+}
@@ -0,0 +1,137 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/wufashanchu/gostrap/pkg/log"
+)
+
+// ReloadEvent 一次配置重新加载的结果
+type ReloadEvent struct {
+	Time     time.Time
+	Source   string         // 触发重新加载的配置源
+	Event    fsnotify.Event // 文件类配置源触发时的原始文件事件
+	Err      error          // 读取、解码、校验失败或回调 panic 时非空，为 nil 表示重新加载成功
+	Diff     Diff           // 重新加载成功时本次的配置变化
+	Panic    any            // 回调 panic 的值
+	Index    int            // panic 的回调序号，仅 Panic 非空时有效
+	Callback string         // panic 的回调函数名
+	Stack    []byte         // panic 时的调用栈
+}
+
+// Panicked 是否由回调 panic 产生
+func (e ReloadEvent) Panicked() bool {
+	return e.Panic != nil
+}
+
+// newReloadEvent 由配置源事件构造重新加载事件
+func newReloadEvent(e Event, err error) ReloadEvent {
+	return ReloadEvent{Source: e.Source, Event: e.FS, Err: err}
+}
+
+// WithOnError 设置重新加载失败或回调 panic 时的处理函数
+func WithOnError(fn func(ReloadEvent)) Option {
+	return func(o *options) {
+		o.onError = append(o.onError, fn)
+	}
+}
+
+// WithEvents 将每次重新加载的结果(成功或失败)发送到 ch，ch 已满时丢弃事件，不阻塞监听
+func WithEvents(ch chan<- ReloadEvent) Option {
+	return func(o *options) {
+		o.events = ch
+	}
+}
+
+// WithLogger 使用 logger 记录重新加载结果，失败以 Error 级别输出
+func WithLogger(logger log.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// report 分发重新加载事件
+func (o *options) report(ev ReloadEvent) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+
+	if o.logger != nil {
+		o.logEvent(ev)
+	}
+	if ev.Err != nil {
+		for _, fn := range o.onError {
+			o.callOnError(fn, ev)
+		}
+	}
+	if o.events != nil {
+		select {
+		case o.events <- ev:
+		default:
+		}
+	}
+}
+
+func (o *options) logEvent(ev ReloadEvent) {
+	fields := []log.Field{log.String("source", ev.Source)}
+	if ev.Event.Name != "" {
+		fields = append(fields, log.String("file", ev.Event.Name), log.String("op", ev.Event.Op.String()))
+	}
+	switch {
+	case ev.Panicked():
+		fields = append(fields,
+			log.Err(ev.Err),
+			log.Int("callback_index", ev.Index),
+			log.String("callback", ev.Callback),
+			log.String("stack", string(ev.Stack)),
+		)
+		o.logger.Error("config reload callback panic", fields...)
+	case ev.Err != nil:
+		o.logger.Error("config reload failed", append(fields, log.Err(ev.Err))...)
+	default:
+		o.logger.Info("config reloaded", append(fields, log.Any("changed", ev.Diff.Keys()))...)
+	}
+}
+
+// callOnError 执行错误处理函数，避免其 panic 导致监听协程退出
+func (o *options) callOnError(fn func(ReloadEvent), ev ReloadEvent) {
+	defer func() {
+		if r := recover(); r != nil && o.logger != nil {
+			o.logger.Error("config OnError handler panic", log.Any("panic", r))
+		}
+	}()
+	fn(ev)
+}
+
+// runCallback 执行单个回调，panic 时上报包含回调序号、名称与调用栈的事件
+func (o *options) runCallback(e Event, index int, name string, fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			ev := newReloadEvent(e, fmt.Errorf("conf: reload callback #%d %s panic: %v", index, name, r))
+			ev.Panic = r
+			ev.Index = index
+			ev.Callback = name
+			ev.Stack = debug.Stack()
+			o.report(ev)
+		}
+	}()
+	fn()
+}
+
+// funcName 返回函数的完整名称
+func funcName(fn any) string {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return ""
+	}
+	if f := runtime.FuncForPC(v.Pointer()); f != nil {
+		return f.Name()
+	}
+	return ""
+}
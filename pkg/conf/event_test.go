@@ -0,0 +1,82 @@
+package conf
+
+import (
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReloadEvents(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, file, "name: v1\n")
+
+	events := make(chan ReloadEvent, 16)
+	var onError atomic.Int32
+	l := NewLoader(file, WithDebounce(10*time.Millisecond), WithEvents(events),
+		WithOnError(func(ReloadEvent) { onError.Add(1) }),
+		WithReload(func() {}))
+	defer l.Close()
+	var cfg precedenceConfig
+	if _, err := l.Load(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, file, "name: v2\n")
+	ev := waitEvent(t, events)
+	if ev.Err != nil || ev.Source == "" || !ev.Diff.Has("name") {
+		t.Fatalf("success event = %+v", ev)
+	}
+	if onError.Load() != 0 {
+		t.Error("OnError called for a successful reload")
+	}
+
+	writeFile(t, file, "name: [v3\n")
+	ev = waitEvent(t, events)
+	if ev.Err == nil || ev.Panicked() {
+		t.Fatalf("failure event = %+v, want a parse error", ev)
+	}
+	if onError.Load() != 1 {
+		t.Errorf("OnError called %d times, want 1", onError.Load())
+	}
+	l.RLock()
+	defer l.RUnlock()
+	if cfg.Name != "v2" {
+		t.Errorf("Name = %q after a failed reload, want v2", cfg.Name)
+	}
+}
+
+func panicReload() { panic("boom") }
+
+func TestReloadCallbackPanic(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, file, "name: v1\n")
+
+	events := make(chan ReloadEvent, 16)
+	ran := make(chan struct{}, 1)
+	l := NewLoader(file, WithDebounce(10*time.Millisecond), WithEvents(events),
+		WithReload(panicReload, func() { ran <- struct{}{} }))
+	defer l.Close()
+	var cfg precedenceConfig
+	if _, err := l.Load(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, file, "name: v2\n")
+	if ev := waitEvent(t, events); ev.Err != nil {
+		t.Fatalf("first event = %+v, want the successful reload", ev)
+	}
+	ev := waitEvent(t, events)
+	if !ev.Panicked() || ev.Panic != "boom" || ev.Index != 0 || len(ev.Stack) == 0 {
+		t.Fatalf("panic event = %+v", ev)
+	}
+	if !strings.HasSuffix(ev.Callback, "panicReload") {
+		t.Errorf("Callback = %q, want panicReload", ev.Callback)
+	}
+	select {
+	case <-ran:
+	case <-time.After(5 * time.Second):
+		t.Fatal("callback after the panicking one did not run")
+	}
+}
@@ -0,0 +1,128 @@
+package conf
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// tagName 解码时使用的结构体标签，与 viper 默认保持一致
+const tagName = "mapstructure"
+
+// fieldKey 返回字段对应的配置键名及是否内联(squash)
+func fieldKey(f reflect.StructField) (key string, squash bool, skip bool) {
+	if !f.IsExported() {
+		return "", false, true
+	}
+	tag := f.Tag.Get(tagName)
+	if tag == "-" {
+		return "", false, true
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	for _, opt := range strings.Split(opts, ",") {
+		switch opt {
+		case "squash":
+			squash = true
+		case "remain":
+			return "", false, true
+		}
+	}
+	if name == "" {
+		name = f.Name
+	}
+	return strings.ToLower(name), squash, false
+}
+
+// isLeafType 判断类型是否作为单个配置值处理，而不再向下展开
+func isLeafType(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return true
+	}
+	return t.PkgPath() == "time" && t.Name() == "Time"
+}
+
+// walkFields 深度优先遍历结构体类型的叶子字段，key 为点分隔的配置键路径
+func walkFields(t reflect.Type, prefix string, fn func(key string, f reflect.StructField)) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, squash, skip := fieldKey(f)
+		if skip {
+			continue
+		}
+		key := joinKey(prefix, name)
+		if squash {
+			key = prefix
+		}
+		if isLeafType(f.Type) {
+			fn(key, f)
+			continue
+		}
+		walkFields(f.Type, key, fn)
+	}
+}
+
+// fieldKeys 返回结构体所有叶子字段的配置键路径
+func fieldKeys(obj any) []string {
+	var keys []string
+	t := reflect.TypeOf(obj)
+	if t == nil {
+		return nil
+	}
+	walkFields(t, "", func(key string, _ reflect.StructField) {
+		keys = append(keys, key)
+	})
+	return keys
+}
+
+func joinKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// walkValues 遍历结构体值的叶子字段，为 nil 的子结构体指针会被跳过
+//
+// path 依次为从顶层到叶子字段经过的结构体字段，最后一个元素为叶子字段本身。
+func walkValues(v reflect.Value, prefix string, fn func(key string, path []reflect.StructField, fv reflect.Value)) {
+	walkPath(v, prefix, nil, fn)
+}
+
+func walkPath(v reflect.Value, prefix string, parents []reflect.StructField, fn func(key string, path []reflect.StructField, fv reflect.Value)) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, squash, skip := fieldKey(f)
+		if skip {
+			continue
+		}
+		key := joinKey(prefix, name)
+		if squash {
+			key = prefix
+		}
+		path := append(slices.Clip(parents), f)
+		if isLeafType(f.Type) {
+			fn(key, path, v.Field(i))
+			continue
+		}
+		walkPath(v.Field(i), key, path, fn)
+	}
+}
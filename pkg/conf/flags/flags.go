@@ -0,0 +1,161 @@
+// Package flags 提供由配置驱动的功能开关
+//
+// 开关定义放在配置文件的一个 section 中，随配置热更新实时生效，无需重启服务：
+//
+//	flags:
+//	  new-checkout:
+//	    enabled: true
+//	    rollout: 20            # 按 key 稳定哈希放量 20%
+//	    allow: [u1001, u1002]  # 总是开启
+//	    deny: [u2001]          # 总是关闭，优先于 allow
+//	    envs: [staging, prod]  # 仅在这些环境生效
+//
+// 配置结构体中以 flags.Config 类型声明该 section，通过 Bind 跟随 conf.Watcher 的快照更新；
+// 使用 conf.Load 时可在 conf.WithReload 回调中调用 Set.Update。
+//
+// 配置键由 viper 统一转为小写，开关名因此不区分大小写，Enabled("newCheckout", key) 与 newcheckout 等价。
+package flags
+
+import (
+	"hash/fnv"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/wufashanchu/gostrap/pkg/conf"
+)
+
+// Flag 单个功能开关的定义
+type Flag struct {
+	Enabled bool     `mapstructure:"enabled"` // 总开关，为 false 时始终关闭
+	Rollout *float64 `mapstructure:"rollout"` // 放量百分比 0-100，未设置表示全量
+	Allow   []string `mapstructure:"allow"`   // 始终开启的 key
+	Deny    []string `mapstructure:"deny"`    // 始终关闭的 key，优先于 Allow
+	Envs    []string `mapstructure:"envs"`    // 生效的环境，为空表示不限制
+}
+
+// Config 开关名到定义的映射，通常作为配置结构体的一个字段
+type Config map[string]Flag
+
+// rolloutBuckets 放量哈希的分桶数，精度为 0.01%
+const rolloutBuckets = 10000
+
+// flag 预处理后的开关定义
+type flag struct {
+	enabled bool
+	buckets uint32 // 放量覆盖的分桶数
+	allow   map[string]struct{}
+	deny    map[string]struct{}
+}
+
+// Set 一组功能开关，并发安全，Update 以原子方式整体替换所有定义，零值表示没有任何开关
+type Set struct {
+	env   string
+	flags atomic.Pointer[map[string]flag]
+}
+
+// New 创建开关集合，env 为当前运行环境，用于匹配 Flag.Envs
+func New(env string, defs Config) *Set {
+	s := &Set{env: env}
+	s.Update(defs)
+	return s
+}
+
+// Update 替换全部开关定义，不在 defs 中的开关视为关闭
+//
+// 超出 0-100 的放量百分比会被截断到该范围内。
+func (s *Set) Update(defs Config) {
+	flags := make(map[string]flag, len(defs))
+	for name, def := range defs {
+		flags[strings.ToLower(name)] = compile(def, s.env)
+	}
+	s.flags.Store(&flags)
+}
+
+// Enabled 判断开关对 key(如用户 ID)是否开启
+//
+// 判定顺序：未定义或 Enabled 为 false、当前环境不在 Envs 中、key 在 Deny 中时关闭；
+// key 在 Allow 中时开启；否则按开关名与 key 的稳定哈希落入放量范围时开启。
+// key 为空时只有全量放量的开关才会开启。
+func (s *Set) Enabled(name, key string) bool {
+	name = strings.ToLower(name)
+	f, ok := s.load()[name]
+	if !ok || !f.enabled {
+		return false
+	}
+	if _, ok := f.deny[key]; ok {
+		return false
+	}
+	if _, ok := f.allow[key]; ok {
+		return true
+	}
+	if f.buckets >= rolloutBuckets {
+		return true
+	}
+	if key == "" || f.buckets == 0 {
+		return false
+	}
+	return bucket(name, key) < f.buckets
+}
+
+// Names 返回当前定义的所有开关名(小写，有序)
+func (s *Set) Names() []string {
+	flags := s.load()
+	names := make([]string, 0, len(flags))
+	for name := range flags {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// load 返回当前的开关定义，未调用过 Update 时为 nil
+func (s *Set) load() map[string]flag {
+	if p := s.flags.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// Bind 以 section 从 w 的快照中取出开关定义，并在每次配置重新加载后更新 s
+func Bind[T any](s *Set, w *conf.Watcher[T], section func(*T) Config) {
+	s.Update(section(w.Load()))
+	w.Subscribe(func(_, cfg *T) {
+		s.Update(section(cfg))
+	})
+}
+
+// compile 预处理开关定义，环境不匹配的开关直接视为关闭
+func compile(def Flag, env string) flag {
+	f := flag{
+		enabled: def.Enabled && (len(def.Envs) == 0 || slices.Contains(def.Envs, env)),
+		buckets: rolloutBuckets,
+		allow:   toSet(def.Allow),
+		deny:    toSet(def.Deny),
+	}
+	if def.Rollout != nil {
+		pct := min(max(*def.Rollout, 0), 100)
+		f.buckets = uint32(pct * rolloutBuckets / 100)
+	}
+	return f
+}
+
+// bucket 返回 key 在开关 name 下的稳定分桶，不同开关的放量人群相互独立
+func bucket(name, key string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(key))
+	return h.Sum32() % rolloutBuckets
+}
+
+func toSet(keys []string) map[string]struct{} {
+	if len(keys) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		set[k] = struct{}{}
+	}
+	return set
+}
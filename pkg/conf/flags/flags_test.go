@@ -0,0 +1,49 @@
+package flags
+
+import "testing"
+
+func TestEnabledCaseInsensitive(t *testing.T) {
+	s := New("prod", Config{"newCheckout": {Enabled: true}})
+	for _, name := range []string{"newCheckout", "newcheckout", "NEWCHECKOUT"} {
+		if !s.Enabled(name, "u1") {
+			t.Errorf("Enabled(%q) = false, want true", name)
+		}
+	}
+	if got := s.Names(); len(got) != 1 || got[0] != "newcheckout" {
+		t.Errorf("Names() = %v", got)
+	}
+}
+
+func TestZeroSet(t *testing.T) {
+	var s Set
+	if s.Enabled("x", "u1") {
+		t.Error("zero Set reports a flag as enabled")
+	}
+	if got := s.Names(); len(got) != 0 {
+		t.Errorf("Names() = %v, want empty", got)
+	}
+}
+
+func TestRollout(t *testing.T) {
+	half := 50.0
+	s := New("prod", Config{
+		"a": {Enabled: true, Rollout: &half, Allow: []string{"vip"}, Deny: []string{"blocked"}},
+		"b": {Enabled: true, Envs: []string{"staging"}},
+	})
+	if !s.Enabled("a", "vip") || s.Enabled("a", "blocked") || s.Enabled("a", "") {
+		t.Error("allow/deny/empty key not honoured")
+	}
+	if s.Enabled("b", "u1") {
+		t.Error("flag enabled outside its envs")
+	}
+
+	on := 0
+	for i := range 10000 {
+		if s.Enabled("a", string(rune('a'+i%26))+string(rune(i))) {
+			on++
+		}
+	}
+	if on < 4500 || on > 5500 {
+		t.Errorf("50%% rollout enabled %d of 10000 keys", on)
+	}
+}
@@ -0,0 +1,157 @@
+package conf
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// 配置来源的名称前缀
+const (
+	OriginFile = "file"
+	OriginEnv  = "env"
+)
+
+// Origins 记录每个配置键(点分隔路径)最终由哪一层提供，如 "file:config.prod.yaml"、"env:ORDERS_LOG_LEVEL"
+type Origins map[string]string
+
+// Of 返回键的来源，未知时返回空字符串
+func (o Origins) Of(key string) string {
+	return o[strings.ToLower(key)]
+}
+
+// Keys 返回所有已记录的键(有序)
+func (o Origins) Keys() []string {
+	keys := make([]string, 0, len(o))
+	for k := range o {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// String 以 "key <- origin" 的形式逐行输出，便于排查线上配置
+func (o Origins) String() string {
+	var b strings.Builder
+	for _, k := range o.Keys() {
+		fmt.Fprintf(&b, "%s <- %s\n", k, o[k])
+	}
+	return b.String()
+}
+
+// layer 一层配置
+type layer struct {
+	src      Source
+	optional bool // 不存在时跳过
+}
+
+func (l layer) name() string {
+	return sourceName(l.src)
+}
+
+// layers 按优先级从低到高返回配置层：基础文件、profile 覆盖文件、本地覆盖文件、WithSource 追加的配置源
+func (o *options) layers(base string) []layer {
+	var ls []layer
+	if base != "" {
+		ls = append(ls, layer{src: NewFileSource(base)})
+		if o.profile != "" {
+			ls = append(ls, layer{src: NewFileSource(profileFile(base, o.profile)), optional: true})
+		}
+	}
+	for _, f := range o.overrideFiles {
+		ls = append(ls, layer{src: NewFileSource(f), optional: true})
+	}
+	for _, src := range o.sources {
+		ls = append(ls, layer{src: src})
+	}
+	return ls
+}
+
+// profileFile 由基础文件推导 profile 文件名：config.yaml -> config.prod.yaml
+func profileFile(base, profile string) string {
+	ext := filepath.Ext(base)
+	return strings.TrimSuffix(base, ext) + "." + profile + ext
+}
+
+// readLayers 依次读取各层并深度合并，后面的层覆盖前面的层，同时返回所有层原始内容的摘要
+func readLayers(ctx context.Context, layers []layer) (map[string]any, Origins, string, error) {
+	merged := make(map[string]any)
+	origins := make(Origins)
+	h := sha256.New()
+	for _, l := range layers {
+		data, format, err := l.src.Read(ctx)
+		if err != nil {
+			if l.optional && errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, nil, "", fmt.Errorf("failed to read configs %s: %w", l.name(), err)
+		}
+		fmt.Fprintf(h, "%s\x00%s\x00%d\x00", l.name(), format, len(data))
+		h.Write(data)
+
+		settings, err := decodeBytes(data, format)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("failed to parse configs %s: %w", l.name(), err)
+		}
+		mergeMap(merged, settings, "", origins, l.name())
+	}
+	return merged, origins, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// mergeMap 将 src 深度合并进 dst，并记录被写入叶子键的来源
+func mergeMap(dst, src map[string]any, prefix string, origins Origins, origin string) {
+	for k, sv := range src {
+		key := joinKey(prefix, k)
+		sm, srcIsMap := sv.(map[string]any)
+		dm, dstIsMap := dst[k].(map[string]any)
+		switch {
+		case srcIsMap && dstIsMap:
+			mergeMap(dm, sm, key, origins, origin)
+		case srcIsMap:
+			forgetOrigins(origins, key)
+			dm = make(map[string]any, len(sm))
+			mergeMap(dm, sm, key, origins, origin)
+			dst[k] = dm
+		default:
+			forgetOrigins(origins, key)
+			dst[k] = sv
+			origins[key] = origin
+		}
+	}
+}
+
+// forgetOrigins 删除 key 及其子键的来源记录
+func forgetOrigins(origins Origins, key string) {
+	delete(origins, key)
+	for k := range origins {
+		if strings.HasPrefix(k, key+".") {
+			delete(origins, k)
+		}
+	}
+}
+
+// envOrigins 标记被环境变量覆盖的键
+func envOrigins(origins Origins, keys []string, o *options) {
+	for _, key := range keys {
+		name := o.envName(key)
+		if val, ok := os.LookupEnv(name); ok && val != "" {
+			origins[key] = OriginEnv + ":" + name
+		}
+	}
+}
+
+// envName 返回配置键对应的环境变量名，规则与 viper 一致
+func (o *options) envName(key string) string {
+	name := key
+	if o.envPrefix != "" {
+		name = o.envPrefix + "_" + key
+	}
+	return o.envKeyReplacer.Replace(strings.ToUpper(name))
+}
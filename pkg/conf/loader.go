@@ -0,0 +1,220 @@
+package conf
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Loader 配置加载器
+//
+// 每个 Loader 拥有独立的 viper 实例、锁和监听协程，不同 Loader 之间互不影响。
+// 注册了重新加载回调时，Load 会在加载成功后开始监听配置源，Close 停止监听并释放 fsnotify 等资源。
+type Loader struct {
+	configFile string
+	opts       *options
+
+	mu      sync.RWMutex // 保护目标对象的写入以及下面的状态
+	v       *viper.Viper // 最近一次成功加载的配置
+	origins Origins
+	secrets map[string]bool
+	hash    string
+
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	watching bool
+}
+
+// NewLoader 创建配置加载器
+func NewLoader(configFile string, opts ...Option) *Loader {
+	return newLoader(configFile, newOptions(opts))
+}
+
+func newLoader(configFile string, o *options) *Loader {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Loader{
+		configFile: configFile,
+		opts:       o,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Load 加载配置并解码到 obj，返回每个键的来源
+//
+// 配置解码到新值并通过校验后才整体替换 obj，加载失败时 obj 保持不变；obj 中原有的值不会保留，默认值请使用 default 标签。
+// 注册了重新加载回调时，首次成功加载后开始监听配置变化，变化时整体替换 obj 并触发回调。
+func (l *Loader) Load(obj any) (Origins, error) {
+	ld, err := load(l.configFile, obj, l.opts)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := reflect.ValueOf(obj)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return nil, fmt.Errorf("failed to unmarshal configs: obj must be a non-nil pointer, got %T", obj)
+	}
+	// 先解码到新值并校验，通过后才写入 obj，校验失败时 obj 保持不变
+	fresh, err := ld.decodeNew(rv.Type().Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	rv.Elem().Set(fresh.Elem())
+	l.setState(ld)
+	startWatch := l.opts.hasCallbacks() && !l.watching
+	l.watching = l.watching || startWatch
+	l.mu.Unlock()
+
+	if startWatch {
+		l.watch(func(e Event) { l.reload(e, obj) })
+	}
+	return ld.origins, nil
+}
+
+// RLock 对目标对象加读锁，与重新加载时的写入互斥
+func (l *Loader) RLock() { l.mu.RLock() }
+
+// RUnlock 释放读锁
+func (l *Loader) RUnlock() { l.mu.RUnlock() }
+
+// Origins 返回最近一次成功加载时每个键的来源
+func (l *Loader) Origins() Origins {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.origins
+}
+
+// Viper 返回最近一次成功加载的 viper 实例，应视为只读
+func (l *Loader) Viper() *viper.Viper {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.v
+}
+
+// Close 停止监听并等待监听协程退出，不要在重新加载回调中调用
+func (l *Loader) Close() error {
+	l.cancel()
+	l.wg.Wait()
+	return nil
+}
+
+// setState 记录一次成功加载的结果，调用方需持有写锁
+func (l *Loader) setState(ld *loaded) {
+	l.v = ld.v
+	l.origins = ld.origins
+	l.secrets = ld.secrets
+	l.hash = ld.hash
+}
+
+// unchanged 判断配置内容与上次成功加载时是否一致
+func (l *Loader) unchanged(ld *loaded) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return ld.hash == l.hash
+}
+
+// reload 重新加载并整体替换 obj
+func (l *Loader) reload(e Event, obj any) {
+	o := l.opts
+	if e.Err != nil {
+		o.report(newReloadEvent(e, e.Err))
+		return
+	}
+
+	ld, err := load(l.configFile, obj, o)
+	if err != nil {
+		o.report(newReloadEvent(e, err))
+		return
+	}
+	if l.unchanged(ld) {
+		return
+	}
+
+	// 先解码到新值并校验，通过后才整体替换 obj，校验失败的配置不会生效
+	fresh, err := ld.decodeNew(reflect.TypeOf(obj).Elem())
+	if err != nil {
+		o.report(newReloadEvent(e, fmt.Errorf("conf: reload rejected: %w", err)))
+		return
+	}
+
+	l.mu.Lock()
+	old := reflect.New(fresh.Elem().Type())
+	old.Elem().Set(reflect.ValueOf(obj).Elem())
+	reflect.ValueOf(obj).Elem().Set(fresh.Elem())
+	secrets := mergeSecrets(l.secrets, ld.secrets)
+	l.setState(ld)
+	l.mu.Unlock()
+
+	diff := compare(old.Interface(), fresh.Interface(), secrets)
+	ev := newReloadEvent(e, nil)
+	ev.Diff = diff
+	o.report(ev)
+	runReloads(o, e, diff)
+}
+
+// watch 监听所有配置层，任一配置源发生变化时调用 onChange
+//
+// 来自不同配置源的事件在同一个协程中串行处理，Close 后不再调用 onChange。
+// 合并窗口内连续到达的事件只以最后一个事件调用一次 onChange，监听错误立即处理。
+func (l *Loader) watch(onChange func(Event)) {
+	ctx := l.ctx
+	events := make(chan Event)
+	for _, ly := range l.opts.layers(l.configFile) {
+		ch := ly.src.Watch(ctx)
+		l.wg.Add(1)
+		go func() {
+			defer l.wg.Done()
+			for ev := range ch {
+				if !sendEvent(ctx, events, ev) {
+					break
+				}
+			}
+			// 排空剩余事件，确保配置源协程能够退出
+			for range ch {
+			}
+		}()
+	}
+
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+
+		debounce := l.opts.debounce
+		timer := time.NewTimer(debounce)
+		timer.Stop()
+		defer timer.Stop()
+
+		var (
+			pending Event
+			fire    <-chan time.Time
+		)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev := <-events:
+				if ev.Err != nil || debounce <= 0 {
+					if ctx.Err() == nil {
+						onChange(ev)
+					}
+					continue
+				}
+				pending = ev
+				timer.Reset(debounce)
+				fire = timer.C
+			case <-fire:
+				fire = nil
+				if ctx.Err() == nil {
+					onChange(pending)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,253 @@
+package conf
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+type precedenceConfig struct {
+	Name string `mapstructure:"name" default:"from-default"`
+}
+
+// flagKind 命令行参数的传入方式
+type flagKind int
+
+const (
+	pflagSet flagKind = iota
+	goFlagSet
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name       string
+		file, env  string
+		flag       string
+		want       string
+		wantOrigin string
+	}{
+		{name: "default only", want: "from-default", wantOrigin: OriginDefault},
+		{name: "file over default", file: "from-file", want: "from-file", wantOrigin: OriginFile},
+		{name: "env over default", env: "from-env", want: "from-env", wantOrigin: OriginEnv},
+		{name: "env over file", file: "from-file", env: "from-env", want: "from-env", wantOrigin: OriginEnv},
+		{name: "flag over default", flag: "from-flag", want: "from-flag", wantOrigin: OriginFlag},
+		{name: "flag over file", file: "from-file", flag: "from-flag", want: "from-flag", wantOrigin: OriginFlag},
+		{name: "flag over env", env: "from-env", flag: "from-flag", want: "from-flag", wantOrigin: OriginFlag},
+		{name: "flag over all", file: "from-file", env: "from-env", flag: "from-flag", want: "from-flag", wantOrigin: OriginFlag},
+	}
+	for _, kind := range []flagKind{pflagSet, goFlagSet} {
+		for _, tt := range tests {
+			name := tt.name
+			if kind == goFlagSet {
+				name += " (go flag)"
+			}
+			t.Run(name, func(t *testing.T) {
+				file := filepath.Join(t.TempDir(), "config.yaml")
+				content := ""
+				if tt.file != "" {
+					content = "name: " + tt.file + "\n"
+				}
+				if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				if tt.env != "" {
+					t.Setenv("TEST_NAME", tt.env)
+				}
+
+				var args []string
+				if tt.flag != "" {
+					args = []string{"--name=" + tt.flag}
+				}
+				opts := []Option{WithEnvPrefix("TEST")}
+				switch kind {
+				case pflagSet:
+					fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+					AddFlags(fs, &precedenceConfig{})
+					if err := fs.Parse(args); err != nil {
+						t.Fatal(err)
+					}
+					opts = append(opts, WithFlags(fs))
+				case goFlagSet:
+					fs := flag.NewFlagSet("test", flag.ContinueOnError)
+					AddGoFlags(fs, &precedenceConfig{})
+					if err := fs.Parse(args); err != nil {
+						t.Fatal(err)
+					}
+					opts = append(opts, WithGoFlags(fs))
+				}
+
+				var cfg precedenceConfig
+				origins, err := Load(file, &cfg, opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if cfg.Name != tt.want {
+					t.Errorf("Name = %q, want %q", cfg.Name, tt.want)
+				}
+				origin := origins.Of("name")
+				if layer, _, _ := strings.Cut(origin, ":"); layer != tt.wantOrigin {
+					t.Errorf("Origins.Of(name) = %q, want layer %q", origin, tt.wantOrigin)
+				}
+			})
+		}
+	}
+}
+
+func TestLoadOriginsDetail(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(file, []byte("name: base\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.prod.yaml"), []byte("name: prod\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg precedenceConfig
+	origins, err := Load(file, &cfg, WithProfile("prod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := OriginFile + ":" + filepath.Join(dir, "config.prod.yaml"); cfg.Name != "prod" || origins.Of("name") != want {
+		t.Errorf("Name = %q from %q, want prod from %q", cfg.Name, origins.Of("name"), want)
+	}
+
+	t.Setenv("ORDERS_NAME", "env")
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	AddFlags(fs, &cfg)
+	if origins, err = Load(file, &cfg, WithEnvPrefix("ORDERS")); err != nil {
+		t.Fatal(err)
+	}
+	if got := origins.Of("name"); got != OriginEnv+":ORDERS_NAME" {
+		t.Errorf("Origins.Of(name) = %q, want env:ORDERS_NAME", got)
+	}
+
+	if err := fs.Parse([]string{"--name=flag"}); err != nil {
+		t.Fatal(err)
+	}
+	if origins, err = Load(file, &cfg, WithEnvPrefix("ORDERS"), WithFlags(fs)); err != nil {
+		t.Fatal(err)
+	}
+	if got := origins.Of("name"); got != OriginFlag+":--name" {
+		t.Errorf("Origins.Of(name) = %q, want flag:--name", got)
+	}
+}
+
+func TestLoaderReloadAndClose(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, []byte("name: v1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := make(chan struct{}, 1)
+	l := NewLoader(file, WithDebounce(10*time.Millisecond), WithReload(func() {
+		select {
+		case reloaded <- struct{}{}:
+		default:
+		}
+	}))
+	var cfg precedenceConfig
+	if _, err := l.Load(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(file, []byte("name: v2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-reloaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reload callback not called")
+	}
+	l.RLock()
+	name := cfg.Name
+	l.RUnlock()
+	if name != "v2" {
+		t.Errorf("Name = %q after reload, want v2", name)
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte("name: v3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	select {
+	case <-reloaded:
+		t.Fatal("reload callback called after Close")
+	default:
+	}
+}
+
+func TestMustLoadWithEnvPrefix(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, []byte("name: file\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ORDERS_NAME", "env")
+
+	var cfg precedenceConfig
+	MustLoadWith(file, &cfg, WithEnvPrefix("ORDERS"))
+	if cfg.Name != "env" {
+		t.Errorf("Name = %q, want env", cfg.Name)
+	}
+}
+
+func TestLoaderDebounceCoalescesWrites(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, file, "name: v0\n")
+
+	var reloads atomic.Int32
+	events := make(chan ReloadEvent, 16)
+	l := NewLoader(file, WithDebounce(200*time.Millisecond), WithEvents(events), WithReload(func() { reloads.Add(1) }))
+	defer l.Close()
+	var cfg precedenceConfig
+	if _, err := l.Load(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= 5; i++ {
+		writeFile(t, file, fmt.Sprintf("name: v%d\n", i))
+		time.Sleep(10 * time.Millisecond)
+	}
+	if ev := waitEvent(t, events); ev.Err != nil {
+		t.Fatal(ev.Err)
+	}
+	time.Sleep(400 * time.Millisecond)
+	if n := reloads.Load(); n != 1 {
+		t.Errorf("reloads = %d, want 1", n)
+	}
+	l.RLock()
+	defer l.RUnlock()
+	if cfg.Name != "v5" {
+		t.Errorf("Name = %q, want v5", cfg.Name)
+	}
+}
+
+func TestLoaderSkipsUnchangedContent(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, file, "name: v1\n")
+
+	events := make(chan ReloadEvent, 16)
+	l := NewLoader(file, WithDebounce(10*time.Millisecond), WithEvents(events), WithReload(func() {}))
+	defer l.Close()
+	var cfg precedenceConfig
+	if _, err := l.Load(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, file, "name: v1\n")
+	select {
+	case ev := <-events:
+		t.Fatalf("reload for unchanged content: %+v", ev)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
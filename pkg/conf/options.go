@@ -0,0 +1,108 @@
+package conf
+
+import (
+	"flag"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/wufashanchu/gostrap/pkg/log"
+)
+
+// DefaultEnvPrefix 默认环境变量前缀(兼容历史配置)
+const DefaultEnvPrefix = "TGBOT"
+
+// DefaultDebounce 默认的配置变化事件合并窗口
+const DefaultDebounce = 100 * time.Millisecond
+
+// Option 配置加载选项
+type Option func(*options)
+
+type options struct {
+	envPrefix      string
+	envKeyReplacer *strings.Replacer
+	reloads        []func()
+	onChange       []func(Diff)
+	profile        string
+	overrideFiles  []string
+	onError        []func(ReloadEvent)
+	events         chan<- ReloadEvent
+	logger         log.Logger
+	resolvers      map[string]SecretResolver
+	sources        []Source
+	debounce       time.Duration
+	flagSets       []*pflag.FlagSet
+	goFlagSets     []*flag.FlagSet
+}
+
+func defaultOptions() *options {
+	return &options{
+		envPrefix:      DefaultEnvPrefix,
+		envKeyReplacer: strings.NewReplacer(".", "_", "-", "_"),
+		resolvers:      defaultResolvers(),
+		debounce:       DefaultDebounce,
+	}
+}
+
+// WithEnvPrefix 设置环境变量前缀，传入空字符串表示不使用前缀
+func WithEnvPrefix(prefix string) Option {
+	return func(o *options) {
+		o.envPrefix = prefix
+	}
+}
+
+// WithEnvKeyReplacer 设置配置键到环境变量名的替换规则
+func WithEnvKeyReplacer(r *strings.Replacer) Option {
+	return func(o *options) {
+		if r != nil {
+			o.envKeyReplacer = r
+		}
+	}
+}
+
+// WithReload 注册配置变更后的回调函数
+func WithReload(reloads ...func()) Option {
+	return func(o *options) {
+		o.reloads = append(o.reloads, reloads...)
+	}
+}
+
+// WithProfile 启用 profile 覆盖文件，如 profile 为 prod 时在基础文件 config.yaml 之上叠加 config.prod.yaml
+func WithProfile(profile string) Option {
+	return func(o *options) {
+		o.profile = profile
+	}
+}
+
+// WithOverrideFile 追加可选的本地覆盖文件，优先级高于 profile 文件，文件不存在时忽略
+func WithOverrideFile(files ...string) Option {
+	return func(o *options) {
+		o.overrideFiles = append(o.overrideFiles, files...)
+	}
+}
+
+// WithDebounce 设置配置变化事件的合并窗口，窗口内的多次变化只触发一次重新加载，0 表示不合并
+func WithDebounce(d time.Duration) Option {
+	return func(o *options) {
+		if d >= 0 {
+			o.debounce = d
+		}
+	}
+}
+
+// hasCallbacks 是否注册了重新加载回调，Load 仅在有回调时监听文件
+func (o *options) hasCallbacks() bool {
+	return len(o.reloads) > 0 || len(o.onChange) > 0
+}
+
+// newOptions 应用 Option 列表
+func newOptions(opts []Option) *options {
+	o := defaultOptions()
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
@@ -1,74 +1,121 @@
 package conf
 
 import (
+	"context"
 	"fmt"
-	"strings"
-	"sync"
+	"reflect"
 
-	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
 )
 
-var (
-	mu sync.RWMutex
-)
-
+// MustLoad 加载配置，失败时 panic，校验失败时 panic 的值为 *ValidationError，列出所有不合法字段
+//
+// 与 Parse 一样保留只接受回调的签名，环境变量前缀固定为 DefaultEnvPrefix；需要传入选项时使用 MustLoadWith。
 func MustLoad(configFile string, obj any, reloads ...func()) {
 	if err := Parse(configFile, obj, reloads...); err != nil {
 		panic(err)
 	}
 }
 
+// MustLoadWith 与 MustLoad 相同，可以设置环境变量前缀、profile 等选项
+//
+//	conf.MustLoadWith(file, &cfg, conf.WithEnvPrefix("ORDERS"), conf.WithReload(onReload))
+func MustLoadWith(configFile string, obj any, opts ...Option) {
+	if _, err := Load(configFile, obj, opts...); err != nil {
+		panic(err)
+	}
+}
+
+// Parse 读取配置文件并解码到 obj，reloads 为配置变更后的回调
+//
+// 为兼容已有调用，Parse 保留只接受回调的签名，不接受 Option，环境变量前缀固定为 DefaultEnvPrefix。
+// 需要设置环境变量前缀、profile 等选项时改用 Load 或 MustLoadWith，原先的 Parse 调用对应写作：
+//
+//	conf.Load(file, &cfg, conf.WithEnvPrefix("ORDERS"), conf.WithReload(onReload))
 func Parse(configFile string, obj any, reloads ...func()) error {
-	// 创建独立的viper实例，避免全局实例带来的冲突
-	v := viper.New()
-	v.SetConfigFile(configFile)
+	_, err := Load(configFile, obj, WithReload(reloads...))
+	return err
+}
 
-	if err := v.ReadInConfig(); err != nil {
-		return fmt.Errorf("failed to read configs file %s: %w", configFile, err)
+// Load 按层加载配置并解码到 obj，返回每个键的来源
+//
+// 优先级从低到高：default 标签 < 基础文件 < config.<profile>.yaml < 本地覆盖文件 < WithSource 配置源 < 环境变量 < WithFlags 命令行参数，
+// map 会被深度合并，后面的层覆盖前面的层。configFile 为空时只使用 WithSource 指定的配置源。
+//
+// 注册了重新加载回调时会持续监听配置变化直到进程退出；需要停止监听时请使用 Loader。
+func Load(configFile string, obj any, opts ...Option) (Origins, error) {
+	o := newOptions(opts)
+	l := newLoader(configFile, o)
+	origins, err := l.Load(obj)
+	if err != nil || !o.hasCallbacks() {
+		_ = l.Close()
 	}
+	return origins, err
+}
 
-	v.AutomaticEnv()
-	v.SetEnvPrefix("TGBOT")
-	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
+// loaded 一次加载合并得到的结果
+type loaded struct {
+	v       *viper.Viper
+	origins Origins
+	secrets map[string]bool // 值由密钥引用解析得到的键
+	hash    string          // 所有配置层原始内容的摘要
+}
+
+// load 读取并合并所有配置层，返回包含最终配置的 viper 实例
+func load(configFile string, obj any, o *options) (*loaded, error) {
+	settings, origins, hash, err := readLayers(context.Background(), o.layers(configFile))
+	if err != nil {
+		return nil, err
+	}
 
-	mu.Lock()
-	err := v.Unmarshal(obj)
-	mu.Unlock()
+	// 创建独立的viper实例，避免全局实例带来的冲突
+	v := viper.New()
+	if err := v.MergeConfigMap(settings); err != nil {
+		return nil, fmt.Errorf("failed to merge configs: %w", err)
+	}
 
+	applyDefaults(v, obj, origins)
+	bindEnv(v, obj, o)
+	envOrigins(origins, v.AllKeys(), o)
+	bindFlags(v, origins, o)
+
+	secrets, err := resolveSecrets(v, o.resolvers)
 	if err != nil {
-		return fmt.Errorf("failed to unmarshal configs: %w", err)
+		return nil, err
 	}
 
-	if len(reloads) > 0 {
-		watchConfig(v, obj, reloads...)
+	return &loaded{v: v, origins: origins, secrets: secrets, hash: hash}, nil
+}
+
+// bindEnv 开启环境变量覆盖，并绑定 obj 中所有字段路径，使文件中不存在的键也能被环境变量设置
+func bindEnv(v *viper.Viper, obj any, o *options) {
+	v.SetEnvPrefix(o.envPrefix)
+	v.SetEnvKeyReplacer(o.envKeyReplacer)
+	v.AutomaticEnv()
+
+	for _, key := range fieldKeys(obj) {
+		_ = v.BindEnv(key)
 	}
+}
 
-	return nil
+// decodeNew 解码到 t 类型的新值并校验，返回指向新值的指针
+func (l *loaded) decodeNew(t reflect.Type) (reflect.Value, error) {
+	fresh := reflect.New(t)
+	if err := l.v.Unmarshal(fresh.Interface()); err != nil {
+		return reflect.Value{}, fmt.Errorf("failed to unmarshal configs: %w", err)
+	}
+	if err := validate(fresh.Interface(), l.secrets); err != nil {
+		return reflect.Value{}, err
+	}
+	return fresh, nil
 }
 
-// watchConfig watches for configuration file changes and triggers reload callbacks.
-func watchConfig(v *viper.Viper, obj any, reloads ...func()) {
-	v.WatchConfig()
-
-	v.OnConfigChange(func(_ fsnotify.Event) {
-		mu.Lock()
-		err := v.Unmarshal(obj)
-		mu.Unlock()
-
-		if err != nil {
-			_ = fmt.Errorf("conf.watchConfig: viper.Unmarshal error: %v", err)
-		} else {
-			// 将 defer/recover 移到循环外面，对所有 reload 函数提供统一的 panic 保护
-			defer func() {
-				if r := recover(); r != nil {
-					_ = fmt.Errorf("conf.watchConfig: reload function panic: %v", r)
-				}
-			}()
-
-			for _, reload := range reloads {
-				reload()
-			}
-		}
-	})
+// runReloads 依次执行回调，单个回调 panic 不影响后续回调
+func runReloads(o *options, e Event, diff Diff) {
+	for i, fn := range o.reloads {
+		o.runCallback(e, i, funcName(fn), fn)
+	}
+	for i, fn := range o.onChange {
+		o.runCallback(e, len(o.reloads)+i, funcName(fn), func() { fn(diff) })
+	}
 }
@@ -0,0 +1,229 @@
+package conf
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// descriptionTag 字段说明，写入 JSON Schema 的 description
+const descriptionTag = "desc"
+
+var registry = struct {
+	sync.RWMutex
+	types map[string]reflect.Type
+}{types: make(map[string]reflect.Type)}
+
+// Register 以 name 注册配置结构体类型，供 gostrap config 等工具按名称创建实例
+func Register(name string, obj any) {
+	t := reflect.TypeOf(obj)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	registry.types[name] = t
+}
+
+// NewRegistered 返回已注册类型的新实例(指针)
+func NewRegistered(name string) (any, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	t, ok := registry.types[name]
+	if !ok {
+		return nil, false
+	}
+	return reflect.New(t).Interface(), true
+}
+
+// Registered 返回所有已注册的名称(有序)
+func Registered() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+	names := make([]string, 0, len(registry.types))
+	for name := range registry.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// JSONSchema 由配置结构体生成 JSON Schema(draft 2020-12)
+//
+// 属性名与解码使用的配置键一致；default 标签写入 default，validate 标签中的
+// required、min、max、len、oneof 转换为对应的约束，secret 字段标记为 writeOnly。
+func JSONSchema(obj any) map[string]any {
+	schema := typeSchema(reflect.TypeOf(obj))
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	return schema
+}
+
+// typeSchema 生成类型对应的 schema
+func typeSchema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == durationType:
+		return map[string]any{"type": "string", "pattern": `^(-?(\d+(\.\d+)?(ns|us|µs|ms|s|m|h))+|0)$`}
+	case t.PkgPath() == "time" && t.Name() == "Time":
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	}
+	return map[string]any{}
+}
+
+// structSchema 生成结构体的 object schema，squash 字段的属性提升到当前层
+func structSchema(t reflect.Type) map[string]any {
+	props := make(map[string]any)
+	var required []string
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, squash, skip := fieldKey(f)
+		if skip {
+			continue
+		}
+		fs := typeSchema(f.Type)
+		if squash {
+			if sub, ok := fs["properties"].(map[string]any); ok {
+				for k, v := range sub {
+					props[k] = v
+				}
+			}
+			if sub, ok := fs["required"].([]string); ok {
+				required = append(required, sub...)
+			}
+			continue
+		}
+		if applyFieldTags(fs, f) {
+			required = append(required, name)
+		}
+		props[name] = fs
+	}
+
+	schema := map[string]any{
+		"type":       "object",
+		"properties": props,
+	}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+// applyFieldTags 将字段标签转换为 schema 约束，返回字段是否必填
+func applyFieldTags(fs map[string]any, f reflect.StructField) (required bool) {
+	if desc := f.Tag.Get(descriptionTag); desc != "" {
+		fs["description"] = desc
+	}
+	if f.Tag.Get(secretTag) == "true" {
+		fs["writeOnly"] = true
+	}
+	if def, ok := f.Tag.Lookup(defaultTag); ok {
+		fs["default"] = schemaValue(fs, def)
+	}
+
+	tag := f.Tag.Get(validateTag)
+	if tag == "" || tag == "-" {
+		return false
+	}
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "required":
+			required = true
+			if fs["type"] == "string" {
+				fs["minLength"] = 1
+			}
+		case "min", "max", "len":
+			applyBound(fs, name, param)
+		case "oneof":
+			var enum []any
+			for _, opt := range strings.Fields(param) {
+				enum = append(enum, schemaValue(fs, opt))
+			}
+			fs["enum"] = enum
+		}
+	}
+	return required
+}
+
+// applyBound 将 min/max/len 转换为与类型相符的约束
+func applyBound(fs map[string]any, name, param string) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	var keys map[string]string
+	switch fs["type"] {
+	case "string":
+		if _, ok := fs["pattern"]; ok {
+			return // time.Duration 的范围无法用 JSON Schema 表达
+		}
+		keys = map[string]string{"min": "minLength", "max": "maxLength"}
+	case "array":
+		keys = map[string]string{"min": "minItems", "max": "maxItems"}
+	case "object":
+		keys = map[string]string{"min": "minProperties", "max": "maxProperties"}
+	case "integer", "number":
+		keys = map[string]string{"min": "minimum", "max": "maximum"}
+		if name == "len" {
+			return
+		}
+	default:
+		return
+	}
+	if name == "len" {
+		fs[keys["min"]], fs[keys["max"]] = n, n
+		return
+	}
+	fs[keys[name]] = n
+}
+
+// schemaValue 将标签中的字符串按 schema 类型转换为 JSON 值
+func schemaValue(fs map[string]any, s string) any {
+	switch fs["type"] {
+	case "integer":
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	case "array":
+		parts := strings.Split(s, ",")
+		out := make([]any, len(parts))
+		for i, p := range parts {
+			out[i] = strings.TrimSpace(p)
+		}
+		return out
+	}
+	return s
+}
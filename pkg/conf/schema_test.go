@@ -0,0 +1,53 @@
+package conf
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+type schemaServer struct {
+	Host string `mapstructure:"host" validate:"required" desc:"listen address"`
+	Port int    `mapstructure:"port" default:"8080" validate:"min=1,max=65535"`
+}
+
+type schemaConfig struct {
+	Server   schemaServer      `mapstructure:",squash"`
+	Mode     string            `mapstructure:"mode" default:"release" validate:"oneof=debug release"`
+	Password string            `mapstructure:"password" secret:"true" validate:"min=8"`
+	Timeout  time.Duration     `mapstructure:"timeout" default:"5s"`
+	Tags     []string          `mapstructure:"tags" default:"a,b" validate:"max=4"`
+	Labels   map[string]string `mapstructure:"labels"`
+	Retry    *struct {
+		Times uint    `mapstructure:"times"`
+		Ratio float64 `mapstructure:"ratio" validate:"max=1"`
+	} `mapstructure:"retry"`
+}
+
+func TestJSONSchemaGolden(t *testing.T) {
+	got, err := json.MarshalIndent(JSONSchema(&schemaConfig{}), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+
+	golden := filepath.Join("testdata", "schema.golden.json")
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("JSONSchema() differs from %s, run go test -update to refresh:\n%s", golden, got)
+	}
+}
@@ -0,0 +1,197 @@
+package conf
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// SecretResolver 解析 ${scheme:ref} 形式的密钥引用
+//
+// 内置 env、file、base64 三种，可通过 WithSecretResolver 注册新的 scheme(如解密、密钥管理服务)，
+// 或替换内置实现。返回的错误不应包含解析出的密钥值。
+type SecretResolver interface {
+	// Scheme 返回处理的引用前缀，如 env
+	Scheme() string
+	// Resolve 返回 ref 对应的明文值
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// EnvResolver 从环境变量读取：${env:DB_PASSWORD}
+type EnvResolver struct{}
+
+// Scheme 实现 SecretResolver
+func (EnvResolver) Scheme() string { return "env" }
+
+// Resolve 实现 SecretResolver
+func (EnvResolver) Resolve(_ context.Context, ref string) (string, error) {
+	val, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", ref)
+	}
+	return val, nil
+}
+
+// FileResolver 从文件读取并去掉末尾换行：${file:/run/secrets/db}
+type FileResolver struct{}
+
+// Scheme 实现 SecretResolver
+func (FileResolver) Scheme() string { return "file" }
+
+// Resolve 实现 SecretResolver
+func (FileResolver) Resolve(_ context.Context, ref string) (string, error) {
+	data, err := os.ReadFile(ref)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// Base64Resolver 解码 base64 字符串：${base64:cGFzc3dvcmQ=}
+type Base64Resolver struct{}
+
+// Scheme 实现 SecretResolver
+func (Base64Resolver) Scheme() string { return "base64" }
+
+// Resolve 实现 SecretResolver
+func (Base64Resolver) Resolve(_ context.Context, ref string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(ref)
+	if err != nil {
+		return "", fmt.Errorf("invalid base64 value")
+	}
+	return string(data), nil
+}
+
+// WithSecretResolver 注册密钥解析器，同名 scheme 会覆盖已有解析器
+func WithSecretResolver(resolvers ...SecretResolver) Option {
+	return func(o *options) {
+		for _, r := range resolvers {
+			o.resolvers[r.Scheme()] = r
+		}
+	}
+}
+
+func defaultResolvers() map[string]SecretResolver {
+	resolvers := make(map[string]SecretResolver)
+	for _, r := range []SecretResolver{EnvResolver{}, FileResolver{}, Base64Resolver{}} {
+		resolvers[r.Scheme()] = r
+	}
+	return resolvers
+}
+
+// secretRef 匹配 ${scheme:ref}
+var secretRef = regexp.MustCompile(`\$\{([a-zA-Z][a-zA-Z0-9_-]*):([^}]*)\}`)
+
+// resolveSecrets 替换所有配置值中的密钥引用，返回被替换过的键
+//
+// 引用可以是整个值，也可以嵌在字符串中，如 postgres://app:${env:DB_PASSWORD}@db:5432/app。
+// 文件、环境变量与默认值中的引用都会被解析。
+func resolveSecrets(v *viper.Viper, resolvers map[string]SecretResolver) (map[string]bool, error) {
+	ctx := context.Background()
+	secrets := make(map[string]bool)
+	for _, key := range v.AllKeys() {
+		val, changed, err := resolveValue(ctx, v.Get(key), resolvers)
+		if err != nil {
+			return nil, fmt.Errorf("conf: resolve secret for key %s: %w", key, err)
+		}
+		if changed {
+			v.Set(key, val)
+			secrets[key] = true
+		}
+	}
+	return secrets, nil
+}
+
+// resolveValue 解析字符串及字符串切片中的引用
+func resolveValue(ctx context.Context, val any, resolvers map[string]SecretResolver) (any, bool, error) {
+	switch x := val.(type) {
+	case string:
+		return resolveString(ctx, x, resolvers)
+	case []any:
+		out := make([]any, len(x))
+		changed := false
+		for i, item := range x {
+			resolved, c, err := resolveValue(ctx, item, resolvers)
+			if err != nil {
+				return nil, false, err
+			}
+			out[i], changed = resolved, changed || c
+		}
+		return out, changed, nil
+	case []string:
+		out := make([]string, len(x))
+		changed := false
+		for i, item := range x {
+			resolved, c, err := resolveString(ctx, item, resolvers)
+			if err != nil {
+				return nil, false, err
+			}
+			out[i], changed = resolved.(string), changed || c
+		}
+		return out, changed, nil
+	}
+	return val, false, nil
+}
+
+func resolveString(ctx context.Context, s string, resolvers map[string]SecretResolver) (any, bool, error) {
+	if !strings.Contains(s, "${") {
+		return s, false, nil
+	}
+	var firstErr error
+	matched := false
+	out := secretRef.ReplaceAllStringFunc(s, func(m string) string {
+		matched = true
+		sub := secretRef.FindStringSubmatch(m)
+		scheme, ref := strings.ToLower(sub[1]), sub[2]
+		r, ok := resolvers[scheme]
+		if !ok {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("no secret resolver for scheme %q", scheme)
+			}
+			return m
+		}
+		val, err := r.Resolve(ctx, ref)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("%s: %w", scheme, err)
+		}
+		return val
+	})
+	if firstErr != nil {
+		return nil, false, firstErr
+	}
+	return out, matched, nil
+}
+
+// isSecretKey 判断 key 或其任一上级键是否为敏感键
+func isSecretKey(secrets map[string]bool, key string) bool {
+	if len(secrets) == 0 {
+		return false
+	}
+	for {
+		if secrets[key] {
+			return true
+		}
+		i := strings.LastIndexByte(key, '.')
+		if i < 0 {
+			return false
+		}
+		key = key[:i]
+	}
+}
+
+// mergeSecrets 合并两组敏感键
+func mergeSecrets(a, b map[string]bool) map[string]bool {
+	out := make(map[string]bool, len(a)+len(b))
+	for k := range a {
+		out[k] = true
+	}
+	for k := range b {
+		out[k] = true
+	}
+	return out
+}
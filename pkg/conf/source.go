@@ -0,0 +1,265 @@
+package conf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// Source 配置源
+//
+// Read 返回完整的配置内容及其格式(yaml、json、toml 等 viper 支持的类型)；
+// Watch 在配置可能发生变化时发送事件，ctx 结束后关闭通道。
+// 所有配置源共用同一条加载与重新加载路径。
+type Source interface {
+	Read(ctx context.Context) ([]byte, string, error)
+	Watch(ctx context.Context) <-chan Event
+}
+
+// Event 配置源变化事件
+type Event struct {
+	Source string         // 配置源名称
+	FS     fsnotify.Event // 文件类配置源的原始事件
+	Err    error          // 监听出错时非空
+}
+
+// WithSource 追加配置源，优先级高于配置文件层，低于环境变量
+func WithSource(sources ...Source) Option {
+	return func(o *options) {
+		o.sources = append(o.sources, sources...)
+	}
+}
+
+// sourceName 返回配置源名称，实现 fmt.Stringer 的配置源使用其 String 结果
+func sourceName(src Source) string {
+	if s, ok := src.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%T", src)
+}
+
+// formatOf 由文件扩展名推断格式
+func formatOf(path string) string {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	if ext == "yml" {
+		return "yaml"
+	}
+	return ext
+}
+
+// decodeBytes 将配置内容解析为小写键的嵌套 map
+func decodeBytes(data []byte, format string) (map[string]any, error) {
+	v := viper.New()
+	v.SetConfigType(format)
+	if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return v.AllSettings(), nil
+}
+
+// sendEvent 发送事件，ctx 结束时放弃
+func sendEvent(ctx context.Context, ch chan<- Event, ev Event) bool {
+	select {
+	case ch <- ev:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// FileSource 本地文件配置源
+type FileSource struct {
+	path string
+}
+
+// NewFileSource 创建文件配置源，格式由扩展名决定
+func NewFileSource(path string) *FileSource {
+	return &FileSource{path: filepath.Clean(path)}
+}
+
+func (s *FileSource) String() string {
+	return OriginFile + ":" + s.path
+}
+
+// Read 实现 Source
+func (s *FileSource) Read(_ context.Context) ([]byte, string, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, formatOf(s.path), nil
+}
+
+// Watch 实现 Source，监听所在目录，文件被创建、修改、删除或替换时发送事件
+//
+// 监听目录而不是文件本身，因此文件被编辑器以重命名方式替换后监听依然有效。
+// 文件为符号链接时同时监听链接目标所在目录，并在链接目标变化时发送事件，
+// 以支持 Kubernetes ConfigMap 通过原子替换 ..data 符号链接的更新方式。
+func (s *FileSource) Watch(ctx context.Context) <-chan Event {
+	target := realPath(s.path)
+	dirs := []string{filepath.Dir(s.path)}
+	if target != "" && filepath.Dir(target) != dirs[0] {
+		dirs = append(dirs, filepath.Dir(target))
+	}
+
+	return watchDirs(ctx, s.String(), dirs, func(e fsnotify.Event) bool {
+		name := filepath.Clean(e.Name)
+		if name == s.path || (target != "" && name == target) {
+			if e.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) == 0 {
+				return false
+			}
+			target = realPath(s.path)
+			return true
+		}
+		// 同目录下其他条目(如 ..data)变化导致链接目标改变
+		if current := realPath(s.path); current != target {
+			target = current
+			return true
+		}
+		return false
+	})
+}
+
+// realPath 返回解析符号链接后的路径，文件不存在时返回空字符串
+func realPath(path string) string {
+	p, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return ""
+	}
+	return p
+}
+
+// DirSource 配置片段目录，目录下的配置文件按文件名排序后依次深度合并
+type DirSource struct {
+	dir string
+}
+
+// NewDirSource 创建目录配置源
+func NewDirSource(dir string) *DirSource {
+	return &DirSource{dir: filepath.Clean(dir)}
+}
+
+func (s *DirSource) String() string {
+	return "dir:" + s.dir
+}
+
+// Read 实现 Source，合并后的结果以 json 格式返回
+func (s *DirSource) Read(_ context.Context) ([]byte, string, error) {
+	files, err := s.fragments()
+	if err != nil {
+		return nil, "", err
+	}
+	merged := make(map[string]any)
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, "", err
+		}
+		settings, err := decodeBytes(data, formatOf(f))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to parse %s: %w", f, err)
+		}
+		mergeMap(merged, settings, "", make(Origins), "")
+	}
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, "json", nil
+}
+
+// Watch 实现 Source，ConfigMap 挂载目录的 ..data 符号链接替换同样会触发事件
+func (s *DirSource) Watch(ctx context.Context) <-chan Event {
+	return watchDirs(ctx, s.String(), []string{s.dir}, func(e fsnotify.Event) bool {
+		return isConfigFile(e.Name) || filepath.Base(e.Name) == configMapDataDir
+	})
+}
+
+// fragments 返回目录下所有支持的配置文件(按文件名排序)
+func (s *DirSource) fragments() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if e.IsDir() || !isConfigFile(e.Name()) {
+			continue
+		}
+		files = append(files, filepath.Join(s.dir, e.Name()))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// isConfigFile 是否为 viper 支持的配置文件(忽略隐藏文件)
+func isConfigFile(name string) bool {
+	base := filepath.Base(name)
+	if strings.HasPrefix(base, ".") {
+		return false
+	}
+	return slices.Contains(viper.SupportedExts, strings.TrimPrefix(filepath.Ext(base), "."))
+}
+
+// configMapDataDir Kubernetes ConfigMap/Secret 挂载目录中指向当前版本数据的符号链接
+const configMapDataDir = "..data"
+
+// watchDirs 监听目录，match 返回 true 的文件事件会被发送，match 只在监听协程中调用
+func watchDirs(ctx context.Context, name string, dirs []string, match func(fsnotify.Event) bool) <-chan Event {
+	ch := make(chan Event)
+	w, err := fsnotify.NewWatcher()
+	if err == nil {
+		for _, dir := range dirs {
+			if err = w.Add(dir); err != nil {
+				err = fmt.Errorf("conf: watch %s: %w", dir, err)
+				_ = w.Close()
+				break
+			}
+		}
+	}
+	if err != nil {
+		go func() {
+			defer close(ch)
+			sendEvent(ctx, ch, Event{Source: name, Err: err})
+		}()
+		return ch
+	}
+
+	go func() {
+		defer close(ch)
+		defer w.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				if e.Op == fsnotify.Chmod || !match(e) {
+					continue
+				}
+				if !sendEvent(ctx, ch, Event{Source: name, FS: e}) {
+					return
+				}
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				if !sendEvent(ctx, ch, Event{Source: name, Err: err}) {
+					return
+				}
+			}
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,178 @@
+package conf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// defaultHTTPInterval HTTPSource 未设置轮询间隔时的默认值
+const defaultHTTPInterval = 30 * time.Second
+
+// HTTPSource 通过 HTTP 轮询获取配置的配置源，支持 ETag 条件请求
+type HTTPSource struct {
+	URL      string
+	Format   string        // 配置格式，为空时根据 Content-Type 或 URL 扩展名推断
+	Interval time.Duration // 轮询间隔，不大于 0 时为 30s
+	Client   *http.Client
+	Header   http.Header // 附加请求头，如鉴权信息
+
+	mu     sync.Mutex
+	etag   string
+	body   []byte
+	format string
+}
+
+// NewHTTPSource 创建 HTTP 配置源
+func NewHTTPSource(url string, interval time.Duration) *HTTPSource {
+	if interval <= 0 {
+		interval = defaultHTTPInterval
+	}
+	return &HTTPSource{
+		URL:      url,
+		Interval: interval,
+		Client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// String 返回去掉用户信息与查询参数的 URL，避免凭据出现在来源、事件与日志中
+func (s *HTTPSource) String() string {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return "http source"
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
+
+// Read 实现 Source，服务端返回 304 时使用本地缓存
+func (s *HTTPSource) Read(ctx context.Context) ([]byte, string, error) {
+	if _, err := s.fetch(ctx); err != nil {
+		return nil, "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.body, s.format, nil
+}
+
+// Watch 实现 Source，每个轮询周期请求一次，内容变化时发送事件
+func (s *HTTPSource) Watch(ctx context.Context) <-chan Event {
+	ch := make(chan Event)
+	go func() {
+		defer close(ch)
+		interval := s.Interval
+		if interval <= 0 {
+			interval = defaultHTTPInterval
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			changed, err := s.fetch(ctx)
+			if err == nil && !changed {
+				continue
+			}
+			if !sendEvent(ctx, ch, Event{Source: s.String(), Err: err}) {
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// fetch 请求配置，返回内容是否发生变化
+func (s *HTTPSource) fetch(ctx context.Context) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		// 解析错误中包含完整的 URL
+		return false, fmt.Errorf("conf: fetch %s: invalid URL", s)
+	}
+	for k, vs := range s.Header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+
+	s.mu.Lock()
+	if s.etag != "" && s.body != nil {
+		req.Header.Set("If-None-Match", s.etag)
+	}
+	s.mu.Unlock()
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// *url.Error 的信息中包含完整的 URL
+		var ue *url.Error
+		if errors.As(err, &ue) {
+			err = ue.Err
+		}
+		return false, fmt.Errorf("conf: fetch %s: %w", s, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return false, nil
+	case http.StatusOK:
+	default:
+		return false, fmt.Errorf("conf: fetch %s: unexpected status %s", s, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("conf: fetch %s: %w", s, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changed := !bytes.Equal(body, s.body)
+	s.etag = resp.Header.Get("ETag")
+	s.body = body
+	s.format = s.detectFormat(resp.Header.Get("Content-Type"))
+	return changed, nil
+}
+
+// detectFormat 推断配置格式
+func (s *HTTPSource) detectFormat(contentType string) string {
+	if s.Format != "" {
+		return s.Format
+	}
+	if mt, _, err := mime.ParseMediaType(contentType); err == nil {
+		switch {
+		case strings.HasSuffix(mt, "json"):
+			return "json"
+		case strings.HasSuffix(mt, "yaml"), strings.HasSuffix(mt, "yml"):
+			return "yaml"
+		case strings.HasSuffix(mt, "toml"):
+			return "toml"
+		}
+	}
+	// 只看路径的扩展名，避免将 https://cfg.example.com 的域名后缀当作格式
+	if u, err := url.Parse(s.URL); err == nil {
+		if f := formatOf(u.Path); slices.Contains(viper.SupportedExts, f) {
+			return f
+		}
+	}
+	return "yaml"
+}
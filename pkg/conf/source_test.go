@@ -0,0 +1,57 @@
+package conf
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileSourceWatchConfigMapSwap(t *testing.T) {
+	dir := t.TempDir()
+	// Kubernetes ConfigMap 挂载目录：config.yaml -> ..data/config.yaml，..data -> ..v1
+	for _, v := range []string{"..v1", "..v2"} {
+		if err := os.Mkdir(filepath.Join(dir, v), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, filepath.Join(dir, v, "config.yaml"), "name: "+v[2:]+"\n")
+	}
+	if err := os.Symlink("..v1", filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "config.yaml")
+	if err := os.Symlink(filepath.Join("..data", "config.yaml"), file); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := NewFileSource(file)
+	ch := s.Watch(ctx)
+
+	// 与 kubelet 相同，先创建临时链接再以重命名原子替换 ..data
+	tmp := filepath.Join(dir, "..data_tmp")
+	if err := os.Symlink("..v2", tmp); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case ev := <-ch:
+		if ev.Err != nil {
+			t.Fatal(ev.Err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event for the ..data swap")
+	}
+	data, _, err := s.Read(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "name: v2\n" {
+		t.Errorf("Read() = %q after swap, want v2", data)
+	}
+}
@@ -0,0 +1,260 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// 结构体标签
+const (
+	defaultTag  = "default"
+	validateTag = "validate"
+)
+
+// OriginDefault 由 default 标签提供的配置来源
+const OriginDefault = "default"
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Key     string // 配置键路径，如 log.level
+	Rule    string // 未通过的规则，如 oneof=json console
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Key + ": " + e.Message
+}
+
+// ValidationError 聚合所有字段的校验错误
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Error())
+	}
+	return "conf: validation failed: " + strings.Join(msgs, "; ")
+}
+
+// applyDefaults 将 default 标签注册为 viper 默认值，解码时由 viper 完成类型转换
+func applyDefaults(v *viper.Viper, obj any, origins Origins) {
+	t := reflect.TypeOf(obj)
+	if t == nil {
+		return
+	}
+	walkFields(t, "", func(key string, f reflect.StructField) {
+		def, ok := f.Tag.Lookup(defaultTag)
+		if !ok {
+			return
+		}
+		v.SetDefault(key, def)
+		if _, set := origins[key]; !set {
+			origins[key] = OriginDefault
+		}
+	})
+}
+
+// Validate 按 validate 标签校验结构体，返回包含全部错误的 *ValidationError
+//
+// 支持的规则：required、min=N、max=N、len=N、oneof=a b c。
+// 对字符串、切片和 map，min/max/len 比较长度；对数值比较大小；time.Duration 的参数写作 1s 形式。
+func Validate(obj any) error {
+	return validate(obj, nil)
+}
+
+// validate 校验结构体，敏感字段(secret 标签或 secrets 中的键)的值不会出现在错误信息中
+func validate(obj any, secrets map[string]bool) error {
+	var errs []FieldError
+	validateValue(reflect.ValueOf(obj), "", secrets, &errs)
+	if len(errs) > 0 {
+		return &ValidationError{Fields: errs}
+	}
+	return nil
+}
+
+// validateValue 深度优先校验结构体值的字段
+//
+// 结构体与结构体指针字段先按自身的规则校验(如 required 要求指针不为 nil)，再校验其内部字段；
+// 为 nil 的结构体指针没有内部字段需要校验。
+func validateValue(v reflect.Value, prefix string, secrets map[string]bool, errs *[]FieldError) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, squash, skip := fieldKey(f)
+		if skip {
+			continue
+		}
+		key := joinKey(prefix, name)
+		if squash {
+			key = prefix
+		}
+		fv := v.Field(i)
+		checkField(key, f, fv, secrets, errs)
+		if !isLeafType(f.Type) {
+			validateValue(fv, key, secrets, errs)
+		}
+	}
+}
+
+// checkField 按 validate 标签校验单个字段
+func checkField(key string, f reflect.StructField, fv reflect.Value, secrets map[string]bool, errs *[]FieldError) {
+	tag := f.Tag.Get(validateTag)
+	if tag == "" || tag == "-" {
+		return
+	}
+	secret := f.Tag.Get(secretTag) == "true" || isSecretKey(secrets, key)
+	for _, rule := range strings.Split(tag, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		if msg := checkRule(fv, rule, secret); msg != "" {
+			*errs = append(*errs, FieldError{Key: key, Rule: rule, Message: msg})
+		}
+	}
+}
+
+// checkRule 校验单条规则，通过时返回空字符串
+func checkRule(v reflect.Value, rule string, secret bool) string {
+	name, param, _ := strings.Cut(rule, "=")
+	ptr := v.Kind() == reflect.Pointer
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			if name == "required" {
+				return "is required"
+			}
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	switch name {
+	case "required":
+		// 结构体指针不为 nil 即满足 required，其内部字段由各自的规则校验
+		if ptr && v.Kind() == reflect.Struct {
+			return ""
+		}
+		if v.IsZero() || (hasLen(v) && v.Len() == 0) {
+			return "is required"
+		}
+	case "min", "max", "len":
+		return checkBound(v, name, param, secret)
+	case "oneof":
+		s := fmt.Sprint(v.Interface())
+		options := strings.Fields(param)
+		for _, opt := range options {
+			if s == opt {
+				return ""
+			}
+		}
+		if secret {
+			s = Redacted
+		}
+		return fmt.Sprintf("must be one of [%s], got %q", strings.Join(options, " "), s)
+	default:
+		return fmt.Sprintf("unknown validation rule %q", rule)
+	}
+	return ""
+}
+
+// checkBound 校验 min/max/len，secret 为 true 时消息中不包含实际的值或长度
+func checkBound(v reflect.Value, name, param string, secret bool) string {
+	if hasLen(v) {
+		n, err := strconv.Atoi(param)
+		if err != nil {
+			return fmt.Sprintf("invalid %s parameter %q", name, param)
+		}
+		return compareBound(name, float64(v.Len()), float64(n), "length ", secret)
+	}
+
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(param)
+		if err != nil {
+			return fmt.Sprintf("invalid %s parameter %q", name, param)
+		}
+		return compareDuration(name, time.Duration(v.Int()), d, secret)
+	}
+
+	var actual, bound float64
+	var err error
+	switch {
+	case v.CanInt():
+		actual = float64(v.Int())
+		bound, err = strconv.ParseFloat(param, 64)
+	case v.CanUint():
+		actual = float64(v.Uint())
+		bound, err = strconv.ParseFloat(param, 64)
+	case v.CanFloat():
+		actual = v.Float()
+		bound, err = strconv.ParseFloat(param, 64)
+	default:
+		return fmt.Sprintf("rule %s is not supported for %s", name, v.Type())
+	}
+	if err != nil {
+		return fmt.Sprintf("invalid %s parameter %q", name, param)
+	}
+	return compareBound(name, actual, bound, "", secret)
+}
+
+func compareBound(name string, actual, bound float64, what string, secret bool) string {
+	fmtNum := func(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+	var msg string
+	switch {
+	case name == "min" && actual < bound:
+		msg = fmt.Sprintf("%smust be at least %s", what, fmtNum(bound))
+	case name == "max" && actual > bound:
+		msg = fmt.Sprintf("%smust be at most %s", what, fmtNum(bound))
+	case name == "len" && actual != bound:
+		msg = fmt.Sprintf("%smust be %s", what, fmtNum(bound))
+	default:
+		return ""
+	}
+	if secret {
+		return msg
+	}
+	return msg + ", got " + fmtNum(actual)
+}
+
+func compareDuration(name string, actual, bound time.Duration, secret bool) string {
+	var msg string
+	switch {
+	case name == "min" && actual < bound:
+		msg = fmt.Sprintf("must be at least %s", bound)
+	case name == "max" && actual > bound:
+		msg = fmt.Sprintf("must be at most %s", bound)
+	case name == "len" && actual != bound:
+		msg = fmt.Sprintf("must be %s", bound)
+	default:
+		return ""
+	}
+	if secret {
+		return msg
+	}
+	return fmt.Sprintf("%s, got %s", msg, actual)
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func hasLen(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return true
+	}
+	return false
+}
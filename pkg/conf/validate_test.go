@@ -0,0 +1,101 @@
+package conf
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type validateDB struct {
+	Host string `mapstructure:"host" validate:"required"`
+}
+
+type validateConfig struct {
+	DB      *validateDB `mapstructure:"db" validate:"required"`
+	Primary validateDB  `mapstructure:"primary" validate:"required"`
+}
+
+func TestValidateStructFields(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  validateConfig
+		want []string
+	}{
+		{
+			name: "nil pointer and zero struct",
+			cfg:  validateConfig{},
+			want: []string{"db", "primary", "primary.host"},
+		},
+		{
+			name: "pointer set, nested field missing",
+			cfg:  validateConfig{DB: &validateDB{}, Primary: validateDB{Host: "p"}},
+			want: []string{"db.host"},
+		},
+		{
+			name: "all set",
+			cfg:  validateConfig{DB: &validateDB{Host: "d"}, Primary: validateDB{Host: "p"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(&tt.cfg)
+			var got []string
+			var ve *ValidationError
+			if errors.As(err, &ve) {
+				for _, f := range ve.Fields {
+					got = append(got, f.Key)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+type secretBoundConfig struct {
+	Port     int    `mapstructure:"port" validate:"max=10"`
+	Password string `mapstructure:"password" secret:"true" validate:"min=8"`
+}
+
+func TestValidateOmitsSecretValues(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, []byte("port: ${env:TEST_SECRET_PORT}\npassword: hunter2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_SECRET_PORT", "12345")
+
+	var cfg secretBoundConfig
+	_, err := Load(file, &cfg)
+	var ve *ValidationError
+	if !errors.As(err, &ve) || len(ve.Fields) != 2 {
+		t.Fatalf("Load() error = %v, want 2 field errors", err)
+	}
+	if msg := err.Error(); strings.Contains(msg, "12345") || strings.Contains(msg, "got") {
+		t.Errorf("error = %q, want no actual values", msg)
+	}
+}
+
+func TestLoadKeepsObjOnValidationError(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, []byte("port: 12345\npassword: longenough\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := secretBoundConfig{Port: 1, Password: "previous"}
+	if _, err := Load(file, &cfg); err == nil {
+		t.Fatal("Load() succeeded, want a validation error")
+	}
+	if cfg.Port != 1 || cfg.Password != "previous" {
+		t.Errorf("cfg = %+v, want it unchanged", cfg)
+	}
+}
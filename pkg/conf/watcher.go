@@ -0,0 +1,132 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Watcher 持有类型为 T 的配置快照
+//
+// 每次配置变化都会解码到一个新的 T 并校验，通过后以原子方式替换当前快照，
+// 读取方通过 Load 拿到的始终是完整、一致的配置，不会看到更新到一半的值。
+// 快照应视为只读，修改它会影响其他读取方。
+type Watcher[T any] struct {
+	loader *Loader
+
+	current atomic.Pointer[T]
+	origins atomic.Pointer[Origins]
+	secrets map[string]bool // 当前快照中由密钥引用解析得到的键，仅在监听协程中访问
+	hash    string          // 当前快照对应的配置内容摘要，仅在监听协程中访问
+
+	subsMu sync.RWMutex
+	subs   []func(old, new *T)
+}
+
+// NewWatcher 加载配置并开始监听文件变化
+func NewWatcher[T any](configFile string, opts ...Option) (*Watcher[T], error) {
+	w := &Watcher[T]{loader: NewLoader(configFile, opts...)}
+
+	snapshot, l, err := w.load()
+	if err != nil {
+		_ = w.loader.Close()
+		return nil, err
+	}
+	w.current.Store(snapshot)
+	w.origins.Store(&l.origins)
+	w.secrets = l.secrets
+	w.hash = l.hash
+
+	// 重新加载在监听协程中串行执行，快照替换顺序与配置变化顺序一致
+	w.loader.watch(w.reload)
+	return w, nil
+}
+
+// MustWatch 创建 Watcher，失败时 panic
+func MustWatch[T any](configFile string, opts ...Option) *Watcher[T] {
+	w, err := NewWatcher[T](configFile, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return w
+}
+
+// Load 返回当前配置快照
+func (w *Watcher[T]) Load() *T {
+	return w.current.Load()
+}
+
+// Origins 返回当前快照中每个键的来源
+func (w *Watcher[T]) Origins() Origins {
+	return *w.origins.Load()
+}
+
+// Close 停止监听配置变化，之后 Load 返回最后一次的快照
+func (w *Watcher[T]) Close() error {
+	return w.loader.Close()
+}
+
+// Subscribe 注册配置变化回调，old 与 new 分别为替换前后的快照
+func (w *Watcher[T]) Subscribe(fn func(old, new *T)) {
+	w.subsMu.Lock()
+	defer w.subsMu.Unlock()
+	w.subs = append(w.subs, fn)
+}
+
+// load 读取全部配置层并解码为新的快照
+func (w *Watcher[T]) load() (*T, *loaded, error) {
+	l, err := load(w.loader.configFile, new(T), w.loader.opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	fresh, err := l.decodeNew(reflect.TypeFor[T]())
+	if err != nil {
+		return nil, nil, err
+	}
+	return fresh.Interface().(*T), l, nil
+}
+
+// reload 重新加载并替换快照，失败时保留旧快照
+func (w *Watcher[T]) reload(e Event) {
+	o := w.loader.opts
+	if e.Err != nil {
+		o.report(newReloadEvent(e, e.Err))
+		return
+	}
+
+	// 与 Loader 一致，先比较内容摘要，内容未变化时不再解码
+	l, err := load(w.loader.configFile, new(T), o)
+	if err != nil {
+		o.report(newReloadEvent(e, err))
+		return
+	}
+	if l.hash == w.hash {
+		return
+	}
+	fresh, err := l.decodeNew(reflect.TypeFor[T]())
+	if err != nil {
+		o.report(newReloadEvent(e, fmt.Errorf("conf: reload rejected: %w", err)))
+		return
+	}
+	snapshot := fresh.Interface().(*T)
+	old := w.current.Swap(snapshot)
+	w.origins.Store(&l.origins)
+	diff := compare(old, snapshot, mergeSecrets(w.secrets, l.secrets))
+	w.secrets = l.secrets
+	w.hash = l.hash
+	ev := newReloadEvent(e, nil)
+	ev.Diff = diff
+	o.report(ev)
+
+	w.subsMu.RLock()
+	subs := make([]func(old, new *T), len(w.subs))
+	copy(subs, w.subs)
+	w.subsMu.RUnlock()
+
+	// 订阅者 panic 不影响其他订阅者，panic 以回调序号与函数名上报
+	for i, fn := range subs {
+		o.runCallback(e, i, funcName(fn), func() { fn(old, snapshot) })
+	}
+	runReloads(o, e, diff)
+}
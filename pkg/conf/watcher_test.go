@@ -0,0 +1,110 @@
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type watchConfig struct {
+	Name string `mapstructure:"name" validate:"required"`
+}
+
+// newTestWatcher 创建监听临时配置文件的 Watcher，重新加载结果发送到返回的 channel
+func newTestWatcher(t *testing.T, content string) (*Watcher[watchConfig], string, chan ReloadEvent) {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, file, content)
+	events := make(chan ReloadEvent, 16)
+	w, err := NewWatcher[watchConfig](file, WithDebounce(10*time.Millisecond), WithEvents(events))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = w.Close() })
+	return w, file, events
+}
+
+func writeFile(t *testing.T, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func waitEvent(t *testing.T, events <-chan ReloadEvent) ReloadEvent {
+	t.Helper()
+	select {
+	case ev := <-events:
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reload event")
+	}
+	return ReloadEvent{}
+}
+
+func TestWatcherSwapsSnapshot(t *testing.T) {
+	w, file, events := newTestWatcher(t, "name: v1\n")
+	first := w.Load()
+
+	swaps := make(chan [2]*watchConfig, 1)
+	w.Subscribe(func(old, new *watchConfig) { swaps <- [2]*watchConfig{old, new} })
+
+	writeFile(t, file, "name: v2\n")
+	if ev := waitEvent(t, events); ev.Err != nil {
+		t.Fatal(ev.Err)
+	}
+	var swap [2]*watchConfig
+	select {
+	case swap = <-swaps:
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscriber not called")
+	}
+	cur := w.Load()
+	if cur == first || cur.Name != "v2" {
+		t.Fatalf("Load() = %+v, want a new snapshot with v2", cur)
+	}
+	if first.Name != "v1" {
+		t.Errorf("previous snapshot changed to %q", first.Name)
+	}
+	if swap[0] != first || swap[1] != cur {
+		t.Errorf("subscriber got %p -> %p, want %p -> %p", swap[0], swap[1], first, cur)
+	}
+}
+
+func TestWatcherKeepsSnapshotOnRejectedReload(t *testing.T) {
+	w, file, events := newTestWatcher(t, "name: v1\n")
+	first := w.Load()
+	var called atomic.Bool
+	w.Subscribe(func(_, _ *watchConfig) { called.Store(true) })
+
+	writeFile(t, file, "name: \"\"\n")
+	if ev := waitEvent(t, events); ev.Err == nil {
+		t.Fatal("reload accepted an invalid config")
+	}
+	if w.Load() != first || first.Name != "v1" {
+		t.Errorf("Load() = %+v, want the previous snapshot", w.Load())
+	}
+	if called.Load() {
+		t.Error("subscriber notified of a rejected reload")
+	}
+}
+
+func TestWatcherClose(t *testing.T) {
+	w, file, events := newTestWatcher(t, "name: v1\n")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, file, "name: v2\n")
+	time.Sleep(100 * time.Millisecond)
+	select {
+	case ev := <-events:
+		t.Fatalf("reload after Close: %+v", ev)
+	default:
+	}
+	if got := w.Load().Name; got != "v1" {
+		t.Errorf("Name = %q after Close, want v1", got)
+	}
+}
@@ -102,6 +102,9 @@ func (m *Manager) Shutdown() {
 	for err := range errChan {
 		m.logger.Error("shutdown error", log.Err(err))
 	}
+
+	// 写出异步日志队列中剩余的日志
+	_ = m.logger.Sync()
 }
 
 // ShutdownHook 全局关闭钩子
@@ -0,0 +1,247 @@
+package log
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// 队列已满时的处理策略
+const (
+	OverflowBlock      = "block"       // 阻塞写入方直到队列有空位
+	OverflowDropNewest = "drop_newest" // 丢弃当前写入的日志
+	OverflowDropOldest = "drop_oldest" // 丢弃队列中最早的日志
+)
+
+// AsyncConfig 异步写入配置，开启后日志先进入有界队列，由后台协程批量写出
+type AsyncConfig struct {
+	Enabled       bool          `json:"enabled" yaml:"enabled" mapstructure:"enabled"`                      // 是否开启异步写入
+	BufferSize    int           `json:"buffer_size" yaml:"buffer_size" mapstructure:"buffer_size"`          // 队列容量(条)，默认 8192
+	FlushInterval time.Duration `json:"flush_interval" yaml:"flush_interval" mapstructure:"flush_interval"` // 批量写出的最长间隔，默认 1s
+	Overflow      string        `json:"overflow" yaml:"overflow" mapstructure:"overflow"`                   // 队列满时的策略: block, drop_newest, drop_oldest，默认 block
+}
+
+// 异步写入的默认参数
+const (
+	defaultAsyncBufferSize    = 8192
+	defaultAsyncFlushInterval = time.Second
+	asyncWriteBufferSize      = 256 * 1024
+)
+
+// ErrAsyncClosed 异步写入器关闭后继续写入时返回
+var ErrAsyncClosed = errors.New("log: async writer closed")
+
+// AsyncWriter 异步的 zapcore.WriteSyncer
+//
+// Write 只把日志放入有界队列，后台协程写入底层输出并按 FlushInterval 刷新；
+// Sync 会等待调用前已入队的日志全部写出并同步底层输出，适合在优雅关闭时调用。
+type AsyncWriter struct {
+	out      zapcore.WriteSyncer
+	overflow string
+	interval time.Duration
+
+	mu      sync.RWMutex // Write 持有读锁入队，Close 持有写锁标记关闭，保证关闭后不再有日志入队
+	closed  bool
+	queue   chan asyncLine
+	flushCh chan chan error
+	done    chan struct{}
+	stopped chan struct{}
+	dropped atomic.Uint64
+}
+
+// NewAsyncWriter 创建异步写入器并启动后台协程，不再使用时需调用 Close
+func NewAsyncWriter(out zapcore.WriteSyncer, cfg AsyncConfig) *AsyncWriter {
+	size := cfg.BufferSize
+	if size <= 0 {
+		size = defaultAsyncBufferSize
+	}
+	overflow := cfg.Overflow
+	switch overflow {
+	case OverflowDropNewest, OverflowDropOldest:
+	default:
+		overflow = OverflowBlock
+	}
+
+	w := &AsyncWriter{
+		out:      out,
+		overflow: overflow,
+		interval: orDefault(cfg.FlushInterval, defaultAsyncFlushInterval),
+		queue:    make(chan asyncLine, size),
+		flushCh:  make(chan chan error),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// asyncLine 队列中的一条日志，level 供按级别写入的输出使用
+type asyncLine struct {
+	b     []byte
+	level zapcore.Level
+}
+
+// Write 实现 zapcore.WriteSyncer，p 会被复制后入队
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	return w.writeLevel(InfoLevel, p)
+}
+
+// writeLevel 实现 levelWriter，底层输出按级别写入时保留日志的级别
+func (w *AsyncWriter) writeLevel(level zapcore.Level, p []byte) (int, error) {
+	b := asyncLine{b: make([]byte, len(p)), level: level}
+	copy(b.b, p)
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return 0, ErrAsyncClosed
+	}
+
+	switch w.overflow {
+	case OverflowDropNewest:
+		select {
+		case w.queue <- b:
+		default:
+			w.drop()
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case w.queue <- b:
+				return len(p), nil
+			default:
+			}
+			select {
+			case <-w.queue:
+				w.drop()
+			default:
+			}
+		}
+	default:
+		// 后台协程在 Close 取得写锁之前持续消费队列，阻塞的写入最终都能完成
+		w.queue <- b
+	}
+	return len(p), nil
+}
+
+// Sync 写出已入队的日志并同步底层输出
+func (w *AsyncWriter) Sync() error {
+	ch := make(chan error, 1)
+	select {
+	case w.flushCh <- ch:
+		return <-ch
+	case <-w.stopped:
+		return nil
+	}
+}
+
+// Close 写出剩余日志并停止后台协程
+//
+// Close 会等待正在进行的 Write 完成，之后的 Write 返回 ErrAsyncClosed，已成功返回的日志都会被写出。
+func (w *AsyncWriter) Close() error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.done)
+	}
+	w.mu.Unlock()
+	<-w.stopped
+	return w.out.Sync()
+}
+
+// Dropped 返回因队列已满被丢弃的日志条数
+func (w *AsyncWriter) Dropped() uint64 {
+	return w.dropped.Load()
+}
+
+func (w *AsyncWriter) drop() {
+	w.dropped.Add(1)
+	asyncDroppedTotal.WithLabelValues(w.overflow).Inc()
+}
+
+// messageWriter 由按消息发送的输出实现，如 tcp/udp 与 syslog，异步写入时每条日志单独写出，不合并为一次写入
+type messageWriter interface {
+	perMessage()
+}
+
+// run 后台写出协程
+//
+// 文件与标准输出等流式输出按整行合并后批量写出，每次写入都以完整的日志结尾，
+// 底层的 RotateWriter 切割时不会把一行日志拆到两个文件；按消息发送的输出逐条写出。
+// 写入失败时丢弃这一批日志，底层输出恢复后继续写出；错误由下一次 Sync 返回。
+func (w *AsyncWriter) run() {
+	defer close(w.stopped)
+
+	_, perMessage := w.out.(messageWriter)
+	lw, _ := w.out.(levelWriter)
+	var batch []byte
+	var werr error // 上次 Sync 之后的首个写入错误
+	fail := func(err error) {
+		if err != nil && werr == nil {
+			werr = err
+		}
+	}
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		_, err := w.out.Write(batch)
+		fail(err)
+		batch = batch[:0]
+	}
+	write := func(line asyncLine) {
+		switch {
+		case !perMessage:
+			if len(batch)+len(line.b) > asyncWriteBufferSize {
+				flush()
+			}
+			batch = append(batch, line.b...)
+		case lw != nil:
+			_, err := lw.writeLevel(line.level, line.b)
+			fail(err)
+		default:
+			_, err := w.out.Write(line.b)
+			fail(err)
+		}
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	// drain 写出当前队列中的全部日志
+	drain := func() {
+		for {
+			select {
+			case b := <-w.queue:
+				write(b)
+			default:
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case b := <-w.queue:
+			write(b)
+		case <-ticker.C:
+			flush()
+		case ch := <-w.flushCh:
+			drain()
+			flush()
+			err := werr
+			werr = nil
+			if serr := w.out.Sync(); err == nil {
+				err = serr
+			}
+			ch <- err
+		case <-w.done:
+			drain()
+			flush()
+			return
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// lockedBuffer 并发安全的 bytes.Buffer
+type lockedBuffer struct {
+	mu sync.Mutex
+	bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.Buffer.Write(p)
+}
+
+func (b *lockedBuffer) Sync() error { return nil }
+
+func (b *lockedBuffer) lines() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return bytes.Count(b.Bytes(), []byte("\n"))
+}
+
+func TestAsyncWriterCloseKeepsAcceptedWrites(t *testing.T) {
+	for range 20 {
+		var out lockedBuffer
+		w := NewAsyncWriter(zapcore.WriteSyncer(&out), AsyncConfig{BufferSize: 16})
+
+		var accepted atomic.Int64
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					if _, err := w.Write([]byte("line\n")); err != nil {
+						if !errors.Is(err, ErrAsyncClosed) {
+							t.Error(err)
+						}
+						return
+					}
+					accepted.Add(1)
+				}
+			}()
+		}
+		for accepted.Load() < 100 {
+			runtime.Gosched()
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		wg.Wait()
+
+		if got, want := out.lines(), int(accepted.Load()); got != want {
+			t.Fatalf("wrote %d lines, %d writes were accepted", got, want)
+		}
+	}
+}
+
+// flakyWriter 第一次写入失败，之后恢复正常
+type flakyWriter struct {
+	lockedBuffer
+	failed atomic.Bool
+	writes atomic.Int64
+}
+
+func (w *flakyWriter) Write(p []byte) (int, error) {
+	if w.failed.CompareAndSwap(false, true) {
+		return 0, errors.New("down")
+	}
+	w.writes.Add(1)
+	return w.lockedBuffer.Write(p)
+}
+
+// messageFlakyWriter 按消息发送的 flakyWriter
+type messageFlakyWriter struct {
+	*flakyWriter
+}
+
+func (messageFlakyWriter) perMessage() {}
+
+func TestAsyncWriterRecoversAfterWriteError(t *testing.T) {
+	for _, message := range []bool{false, true} {
+		out := &flakyWriter{}
+		var ws zapcore.WriteSyncer = out
+		if message {
+			ws = messageFlakyWriter{out}
+		}
+		w := NewAsyncWriter(ws, AsyncConfig{})
+
+		_, _ = w.Write([]byte("lost\n"))
+		if err := w.Sync(); err == nil || err.Error() != "down" {
+			t.Fatalf("message=%v: first Sync = %v, want down", message, err)
+		}
+		for range 3 {
+			_, _ = w.Write([]byte("line\n"))
+		}
+		if err := w.Sync(); err != nil {
+			t.Fatalf("message=%v: Sync after recovery = %v", message, err)
+		}
+		if got := out.lines(); got != 3 {
+			t.Errorf("message=%v: lines = %d, want 3", message, got)
+		}
+		if message && out.writes.Load() != 3 {
+			t.Errorf("writes = %d, want one per line", out.writes.Load())
+		}
+		_ = w.Close()
+	}
+}
+
+func TestAsyncWriterKeepsLinesWholeAcrossRotation(t *testing.T) {
+	dir := t.TempDir()
+	rw, err := NewRotateWriter(RotateConfig{Filename: filepath.Join(dir, "app.log"), MaxSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := NewAsyncWriter(rw, AsyncConfig{})
+	line := []byte(strings.Repeat("x", 36) + "\n")
+	for range 3 << 20 / len(line) {
+		if _, err := w.Write(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "app-*.log"))
+	if err != nil || len(files) < 2 {
+		t.Fatalf("backups = %v, %v, want at least 2", files, err)
+	}
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(b) == 0 || b[len(b)-1] != '\n' || len(b)%len(line) != 0 {
+			t.Errorf("%s: %d bytes, want whole lines", filepath.Base(f), len(b))
+		}
+	}
+}
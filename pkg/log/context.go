@@ -0,0 +1,69 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ContextExtractor 从 context 中提取日志字段，如请求 ID、租户 ID
+type ContextExtractor func(ctx context.Context) []Field
+
+var (
+	extractorsMu sync.RWMutex
+	extractors   []ContextExtractor
+)
+
+// RegisterContextExtractor 注册 WithContext 使用的字段提取函数，按注册顺序在追踪字段之后追加
+//
+// 通常在 init 或程序启动时注册：
+//
+//	log.RegisterContextExtractor(log.ContextValue(requestIDKey{}, "request_id"))
+func RegisterContextExtractor(fns ...ContextExtractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+	extractors = append(extractors, fns...)
+}
+
+// ContextValue 返回提取 ctx.Value(key) 的 ContextExtractor，值存在时以 field 为字段名输出
+//
+// 字符串与 fmt.Stringer 按字符串输出，其他类型按 Any 输出。
+func ContextValue(key any, field string) ContextExtractor {
+	return func(ctx context.Context) []Field {
+		switch v := ctx.Value(key).(type) {
+		case nil:
+			return nil
+		case string:
+			if v == "" {
+				return nil
+			}
+			return []Field{String(field, v)}
+		case fmt.Stringer:
+			return []Field{String(field, v.String())}
+		default:
+			return []Field{Any(field, v)}
+		}
+	}
+}
+
+// extractTraceFields 从 context 提取当前 OpenTelemetry span 的 trace_id、span_id、trace_flags 以及已注册提取函数的字段
+func extractTraceFields(ctx context.Context) []Field {
+	var fields []Field
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields = append(fields,
+			String("trace_id", sc.TraceID().String()),
+			String("span_id", sc.SpanID().String()),
+			String("trace_flags", sc.TraceFlags().String()),
+		)
+	}
+
+	extractorsMu.RLock()
+	fns := extractors
+	extractorsMu.RUnlock()
+	for _, fn := range fns {
+		fields = append(fields, fn(ctx)...)
+	}
+	return fields
+}
@@ -0,0 +1,52 @@
+package log
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type requestIDKey struct{}
+
+type tenant string
+
+func (t tenant) String() string { return "tenant-" + string(t) }
+
+type tenantKey struct{}
+
+func TestContextExtractors(t *testing.T) {
+	extractorsMu.Lock()
+	saved := extractors
+	extractorsMu.Unlock()
+	t.Cleanup(func() {
+		extractorsMu.Lock()
+		extractors = saved
+		extractorsMu.Unlock()
+	})
+	RegisterContextExtractor(
+		ContextValue(requestIDKey{}, "request_id"),
+		ContextValue(tenantKey{}, "tenant"),
+		func(ctx context.Context) []Field { return []Field{Bool("custom", true)} },
+	)
+
+	obs, logs := observer.New(DebugLevel)
+	l := NewFromCore(obs)
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req-1")
+	ctx = context.WithValue(ctx, tenantKey{}, tenant("acme"))
+	l.WithContext(ctx).Info("with values")
+	l.WithContext(context.Background()).Info("without values")
+
+	m := logs.All()[0].ContextMap()
+	if m["request_id"] != "req-1" || m["tenant"] != "tenant-acme" || m["custom"] != true {
+		t.Errorf("fields = %v", m)
+	}
+	if _, ok := m["trace_id"]; ok {
+		t.Error("trace_id added without a span")
+	}
+	m = logs.All()[1].ContextMap()
+	if _, ok := m["request_id"]; ok || m["custom"] != true {
+		t.Errorf("fields without values = %v, want only custom", m)
+	}
+}
@@ -0,0 +1,136 @@
+package log
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// globals 全局日志状态
+type globals struct {
+	l      Logger // 当前的全局日志
+	caller Logger // 供包级日志函数使用，多跳过一层调用栈，使 caller 指向包级函数的调用方
+	key    string // 由 Init 创建时为其配置的摘要，用于 Reload 判断配置是否变化
+	owned  bool   // 由本包创建(默认 logger 或 Init)，被 Init 替换时关闭
+}
+
+// global 当前的全局日志，Init 之前为输出到标准错误的默认 logger，包级函数在任何时候调用都是安全的
+var global atomic.Pointer[globals]
+
+func init() {
+	global.Store(newGlobals(defaultLogger(), "", true))
+}
+
+func newGlobals(l Logger, key string, owned bool) *globals {
+	g := &globals{l: l, caller: l, key: key, owned: owned}
+	if zl, ok := l.(*logger); ok {
+		g.caller = zl.derive(zl.zap.WithOptions(zap.AddCallerSkip(1)))
+	}
+	return g
+}
+
+// defaultLogger Init 之前使用的全局日志，以 console 格式将 Info 及以上的日志输出到标准错误
+func defaultLogger() Logger {
+	cfg := DefaultConfig()
+	cfg.Format = "console"
+	cfg.Sinks = []SinkConfig{{Type: SinkStderr}}
+	return New(cfg)
+}
+
+// NewNop 创建丢弃所有日志的 Logger
+func NewNop() Logger {
+	return NewFromCore(zapcore.NewNopCore())
+}
+
+// L 返回当前的全局日志
+func L() Logger {
+	return global.Load().l
+}
+
+// Init 按 cfg 创建日志并设置为全局日志，可重复调用以重新配置
+//
+// 原全局日志由 Init 创建(或为默认 logger)时会被关闭：新日志仍在使用的输出(如同一日志文件)继续共享，
+// 之前通过 With 等派生的 logger 仍可写入；不再使用的输出被关闭，写入这些输出会失败。
+// 通过 ReplaceGlobal 设置的 logger 由调用方负责关闭。
+func Init(cfg *Config) Logger {
+	if cfg == nil {
+		cfg = DefaultConfig()
+	}
+	l := New(cfg)
+	prev := global.Load()
+	replaceGlobal(l, configKey(cfg), true)
+	if prev.owned {
+		if c, ok := prev.l.(io.Closer); ok {
+			_ = c.Close()
+		}
+	} else {
+		_ = prev.l.Sync()
+	}
+	return l
+}
+
+// ReplaceGlobal 将 l 设置为全局日志，l 为 nil 时使用 NewNop，返回恢复原全局日志的函数，常用于测试：
+//
+//	logger, rec := logtest.New()
+//	defer log.ReplaceGlobal(logger)()
+//
+// 标准库 log 包与 zap 的全局 logger(zap.L、zap.S)同时被重定向到 l，恢复时一并还原。
+func ReplaceGlobal(l Logger) (restore func()) {
+	return replaceGlobal(l, "", false)
+}
+
+func replaceGlobal(l Logger, key string, owned bool) func() {
+	if l == nil {
+		l = NewNop()
+	}
+	prev := global.Swap(newGlobals(l, key, owned))
+
+	z := zapOf(l)
+	undoZap := zap.ReplaceGlobals(z)
+	undoStd := zap.RedirectStdLog(z)
+	return func() {
+		undoStd()
+		undoZap()
+		global.Store(prev)
+	}
+}
+
+// zapOf 返回写入 l 的 zap.Logger，caller 指向直接调用 zap.Logger 的位置
+func zapOf(l Logger) *zap.Logger {
+	if zl, ok := l.(*logger); ok {
+		return zl.zap.WithOptions(zap.AddCallerSkip(-1))
+	}
+	return zap.New(loggerCore{l})
+}
+
+// Reload 返回可注册到 conf.WithReload 的回调，配置重新加载后按 cfg 重新配置全局日志
+//
+//	var cfg Config
+//	conf.MustLoadWith(file, &cfg, conf.WithEnvPrefix("ORDERS"), conf.WithReload(log.Reload(&cfg.Log)))
+//
+// 只有 Level 变化时与 ReloadLevel 相同，仅修改级别；格式、输出等其他配置变化时通过 Init 重建全局日志，
+// 此时通过 HTTP 设置的临时级别会被取消。
+func Reload(cfg *Config) func() {
+	var mu sync.Mutex
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if configKey(cfg) == global.Load().key {
+			ReloadLevel(cfg)()
+			return
+		}
+		Init(cfg)
+	}
+}
+
+// configKey 返回除 Level 外的配置摘要
+func configKey(cfg *Config) string {
+	c := *cfg
+	c.Level = ""
+	b, _ := json.Marshal(c)
+	return string(b)
+}
@@ -0,0 +1,65 @@
+package log
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReloadClosesReplacedWriters(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &Config{
+		Level:  "info",
+		Format: "json",
+		Sinks:  []SinkConfig{{Type: SinkFile, Filename: filepath.Join(dir, "a.log")}},
+		Async:  AsyncConfig{Enabled: true},
+	}
+	defer ReplaceGlobal(NewNop())()
+	// 只统计本测试目录下的输出，默认 logger 持有的标准错误不计入
+	writers := func() int {
+		sharedMu.Lock()
+		defer sharedMu.Unlock()
+		n := 0
+		for k := range sharedWriters {
+			if strings.Contains(k, dir) {
+				n++
+			}
+		}
+		return n
+	}
+
+	Init(cfg)
+	defer func() { _ = L().(io.Closer).Close() }()
+	held := With(String("k", "v"))
+	reload := Reload(cfg)
+	for i := range 5 {
+		cfg.Format = []string{"json", "console"}[i%2]
+		reload()
+	}
+	if n := writers(); n != 2 {
+		t.Fatalf("shared writers = %d after reloads, want 2 (file and its async writer)", n)
+	}
+
+	held.Info("held")
+	if err := Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "a.log")); !strings.Contains(string(b), `"msg":"held"`) {
+		t.Fatalf("logger held across reloads did not write to the shared file: %q", b)
+	}
+
+	cfg.Sinks = []SinkConfig{{Type: SinkFile, Filename: filepath.Join(dir, "b.log")}}
+	cfg.Async.Enabled = false
+	reload()
+	if n := writers(); n != 1 {
+		t.Fatalf("shared writers = %d after switching files, want 1", n)
+	}
+	if err := L().(io.Closer).Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := writers(); n != 0 {
+		t.Fatalf("shared writers = %d after Close, want 0", n)
+	}
+}
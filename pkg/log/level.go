@@ -0,0 +1,218 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// AtomicLevel 可在运行时修改的日志级别，并发安全
+//
+// 通过 SetLevelFor 设置的临时级别在到期后自动恢复为基础级别，
+// 便于在单个实例上临时打开 debug 日志而无需重新部署。
+type AtomicLevel struct {
+	level zap.AtomicLevel
+
+	mu      sync.Mutex
+	base    Level       // 临时级别到期后恢复的级别
+	expires time.Time   // 临时级别的到期时间，零值表示没有临时级别
+	timer   *time.Timer // 到期后恢复基础级别
+}
+
+// NewAtomicLevel 创建初始级别为 l 的 AtomicLevel
+func NewAtomicLevel(l Level) *AtomicLevel {
+	return &AtomicLevel{level: zap.NewAtomicLevelAt(l), base: l}
+}
+
+// Enabled 实现 zapcore.LevelEnabler
+func (a *AtomicLevel) Enabled(l Level) bool {
+	return a.level.Enabled(l)
+}
+
+// Level 返回当前生效的级别
+func (a *AtomicLevel) Level() Level {
+	return a.level.Level()
+}
+
+// SetLevel 设置级别，同时取消尚未到期的临时级别
+func (a *AtomicLevel) SetLevel(l Level) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.stopTimer()
+	a.base = l
+	a.level.SetLevel(l)
+}
+
+// SetLevelFor 临时将级别设置为 l，ttl 后恢复为调用前的基础级别，ttl 不大于 0 时等同于 SetLevel
+func (a *AtomicLevel) SetLevelFor(l Level, ttl time.Duration) {
+	if ttl <= 0 {
+		a.SetLevel(l)
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.stopTimer()
+	a.level.SetLevel(l)
+	a.expires = time.Now().Add(ttl)
+
+	var timer *time.Timer
+	timer = time.AfterFunc(ttl, func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		// 计时器已被新的设置替换时不再恢复
+		if a.timer != timer {
+			return
+		}
+		a.timer = nil
+		a.expires = time.Time{}
+		a.level.SetLevel(a.base)
+	})
+	a.timer = timer
+}
+
+// Expires 返回临时级别的到期时间，没有临时级别时返回零值
+func (a *AtomicLevel) Expires() time.Time {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.expires
+}
+
+// stopTimer 取消临时级别，调用方需持有锁
+func (a *AtomicLevel) stopTimer() {
+	if a.timer != nil {
+		a.timer.Stop()
+		a.timer = nil
+	}
+	a.expires = time.Time{}
+}
+
+// levelPayload 级别接口的请求与响应
+//
+//	GET  -> {"level":"debug","base":"info","expires_at":"2024-01-01T10:10:00Z"}
+//	PUT  <- {"level":"debug","ttl":"10m"}
+type levelPayload struct {
+	Level     string     `json:"level"`
+	Base      string     `json:"base,omitempty"`
+	TTL       string     `json:"ttl,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// ServeHTTP 查询(GET)或修改(PUT)日志级别，PUT 可携带 ttl 设置临时级别
+func (a *AtomicLevel) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var req levelPayload
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeLevelError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+			return
+		}
+		l, err := ParseLevel(req.Level)
+		if err != nil {
+			writeLevelError(w, http.StatusBadRequest, err)
+			return
+		}
+		var ttl time.Duration
+		if req.TTL != "" {
+			if ttl, err = time.ParseDuration(req.TTL); err != nil {
+				writeLevelError(w, http.StatusBadRequest, fmt.Errorf("invalid ttl %q: %w", req.TTL, err))
+				return
+			}
+		}
+		a.SetLevelFor(l, ttl)
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		writeLevelError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	a.mu.Lock()
+	resp := levelPayload{Level: a.level.Level().String(), Base: a.base.String()}
+	if !a.expires.IsZero() {
+		expires := a.expires
+		resp.ExpiresAt = &expires
+	}
+	a.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func writeLevelError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// ParseLevel 解析日志级别名称，如 debug、info、warn、error
+func ParseLevel(s string) (Level, error) {
+	var l zapcore.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return l, fmt.Errorf("log: unknown level %q", s)
+	}
+	return l, nil
+}
+
+// LevelOf 返回 logger 的运行时级别，logger 不支持时返回 nil
+func LevelOf(l Logger) *AtomicLevel {
+	if lv, ok := l.(interface{ AtomicLevel() *AtomicLevel }); ok {
+		return lv.AtomicLevel()
+	}
+	return nil
+}
+
+// SetLevel 修改全局日志的级别
+func SetLevel(l Level) {
+	if a := LevelOf(L()); a != nil {
+		a.SetLevel(l)
+	}
+}
+
+// SetLevelFor 临时修改全局日志的级别，ttl 后恢复
+func SetLevelFor(l Level, ttl time.Duration) {
+	if a := LevelOf(L()); a != nil {
+		a.SetLevelFor(l, ttl)
+	}
+}
+
+// LevelHandler 返回查询和修改全局日志级别的 http.Handler，请求格式见 AtomicLevel.ServeHTTP
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a := LevelOf(L())
+		if a == nil {
+			writeLevelError(w, http.StatusServiceUnavailable, fmt.Errorf("global logger does not support runtime level"))
+			return
+		}
+		a.ServeHTTP(w, r)
+	})
+}
+
+// ReloadLevel 返回可注册到 conf.WithReload 的回调，配置重新加载后按 cfg.Level 更新全局日志级别
+//
+//	var cfg Config
+//	conf.MustLoadWith(file, &cfg, conf.WithEnvPrefix("ORDERS"), conf.WithReload(log.ReloadLevel(&cfg.Log)))
+//
+// 只有 cfg.Level 与当前基础级别不同时才会修改，其他配置变化不会取消通过 HTTP 设置的临时级别。
+func ReloadLevel(cfg *Config) func() {
+	return func() {
+		if a := LevelOf(L()); a != nil {
+			a.setBase(parseLevel(cfg.Level))
+		}
+	}
+}
+
+// setBase 基础级别变化时调用 SetLevel
+func (a *AtomicLevel) setBase(l Level) {
+	a.mu.Lock()
+	same := a.base == l
+	a.mu.Unlock()
+	if !same {
+		a.SetLevel(l)
+	}
+}
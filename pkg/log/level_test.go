@@ -0,0 +1,84 @@
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAtomicLevelServeHTTP(t *testing.T) {
+	a := NewAtomicLevel(InfoLevel)
+	srv := httptest.NewServer(a)
+	defer srv.Close()
+
+	do := func(method, body string) (int, levelPayload) {
+		t.Helper()
+		req, err := http.NewRequest(method, srv.URL, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var p levelPayload
+		_ = json.NewDecoder(resp.Body).Decode(&p)
+		return resp.StatusCode, p
+	}
+
+	if code, p := do(http.MethodGet, ""); code != http.StatusOK || p.Level != "info" || p.ExpiresAt != nil {
+		t.Fatalf("GET = %d %+v", code, p)
+	}
+	if code, p := do(http.MethodPut, `{"level":"debug","ttl":"1h"}`); code != http.StatusOK || p.Level != "debug" || p.Base != "info" || p.ExpiresAt == nil {
+		t.Fatalf("PUT with ttl = %d %+v", code, p)
+	}
+	if !a.Enabled(DebugLevel) {
+		t.Error("debug not enabled after PUT")
+	}
+	if code, p := do(http.MethodPut, `{"level":"warn"}`); code != http.StatusOK || p.Level != "warn" || p.Base != "warn" || p.ExpiresAt != nil {
+		t.Fatalf("PUT = %d %+v", code, p)
+	}
+
+	for _, body := range []string{`{"level":"loud"}`, `{"level":"debug","ttl":"soon"}`, `not json`} {
+		if code, _ := do(http.MethodPut, body); code != http.StatusBadRequest {
+			t.Errorf("PUT %s = %d, want 400", body, code)
+		}
+	}
+	if code, _ := do(http.MethodPost, ""); code != http.StatusMethodNotAllowed {
+		t.Errorf("POST = %d, want 405", code)
+	}
+	if a.Level() != WarnLevel {
+		t.Errorf("level = %v after rejected requests, want warn", a.Level())
+	}
+}
+
+func TestAtomicLevelTTLReverts(t *testing.T) {
+	a := NewAtomicLevel(InfoLevel)
+	a.SetLevelFor(DebugLevel, 20*time.Millisecond)
+	if a.Level() != DebugLevel || a.Expires().IsZero() {
+		t.Fatalf("level = %v, expires = %v, want a temporary debug level", a.Level(), a.Expires())
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for a.Level() != InfoLevel {
+		if time.Now().After(deadline) {
+			t.Fatal("level did not revert after the TTL")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !a.Expires().IsZero() {
+		t.Errorf("Expires() = %v after revert, want zero", a.Expires())
+	}
+
+	// 新的设置取代尚未到期的临时级别，旧的计时器不再恢复
+	a.SetLevelFor(DebugLevel, 20*time.Millisecond)
+	a.SetLevel(ErrorLevel)
+	time.Sleep(50 * time.Millisecond)
+	if a.Level() != ErrorLevel {
+		t.Errorf("level = %v, want error after SetLevel cancelled the TTL", a.Level())
+	}
+}
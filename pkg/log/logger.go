@@ -2,12 +2,14 @@ package log
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"sync"
 
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 // Level 日志级别
@@ -29,8 +31,24 @@ type Logger interface {
 	Warn(msg string, fields ...Field)
 	Error(msg string, fields ...Field)
 	Fatal(msg string, fields ...Field)
+
+	// printf 风格
+	Debugf(template string, args ...any)
+	Infof(template string, args ...any)
+	Warnf(template string, args ...any)
+	Errorf(template string, args ...any)
+	Fatalf(template string, args ...any)
+
+	// 键值对风格，如 Infow("user login", "user_id", 42)，键值对中也可以混用 Field
+	Debugw(msg string, keysAndValues ...any)
+	Infow(msg string, keysAndValues ...any)
+	Warnw(msg string, keysAndValues ...any)
+	Errorw(msg string, keysAndValues ...any)
+	Fatalw(msg string, keysAndValues ...any)
+
 	With(fields ...Field) Logger
 	WithContext(ctx context.Context) Logger
+	Named(name string) Logger // 创建子 logger，名称以 . 连接
 	Sync() error
 }
 
@@ -52,13 +70,20 @@ var (
 
 // Config 日志配置
 type Config struct {
-	Level      string `json:"level" yaml:"level"`             // 日志级别
-	Format     string `json:"format" yaml:"format"`           // 输出格式: json, console
-	Filename   string `json:"filename" yaml:"filename"`       // 日志文件路径
-	MaxSize    int    `json:"max_size" yaml:"max_size"`       // 单文件最大大小(MB)
-	MaxBackups int    `json:"max_backups" yaml:"max_backups"` // 最大备份数
-	MaxAge     int    `json:"max_age" yaml:"max_age"`         // 最大保留天数
-	Compress   bool   `json:"compress" yaml:"compress"`       // 是否压缩
+	Level      string `json:"level" yaml:"level" mapstructure:"level"`                   // 日志级别
+	Format     string `json:"format" yaml:"format" mapstructure:"format"`                // 输出格式: json, console
+	Filename   string `json:"filename" yaml:"filename" mapstructure:"filename"`          // 日志文件路径，未配置 Sinks 时在标准输出之外写入该文件
+	MaxSize    int    `json:"max_size" yaml:"max_size" mapstructure:"max_size"`          // 单文件最大大小(MB)，为 0 时使用默认的 100MB，小于 0 时不按大小切割
+	MaxBackups int    `json:"max_backups" yaml:"max_backups" mapstructure:"max_backups"` // 最大备份数
+	MaxAge     int    `json:"max_age" yaml:"max_age" mapstructure:"max_age"`             // 最大保留天数
+	Compress   bool   `json:"compress" yaml:"compress" mapstructure:"compress"`          // 是否压缩备份文件，压缩在后台进行
+	Rotate     string `json:"rotate" yaml:"rotate" mapstructure:"rotate"`                // 按时间切割的周期: daily, hourly，为空时只按大小切割
+	SpanEvents string `json:"span_events" yaml:"span_events" mapstructure:"span_events"` // 不低于该级别的日志同时记录为 span 事件，为空时不记录
+
+	Sampling SamplingConfig `json:"sampling" yaml:"sampling" mapstructure:"sampling"` // 采样与限流
+	Async    AsyncConfig    `json:"async" yaml:"async" mapstructure:"async"`          // 异步写入
+	Sinks    []SinkConfig   `json:"sinks" yaml:"sinks" mapstructure:"sinks"`          // 日志输出，配置后替代标准输出与 Filename
+	Redact   []RedactRule   `json:"redact" yaml:"redact" mapstructure:"redact"`       // 敏感字段脱敏规则
 }
 
 // DefaultConfig 默认配置
@@ -77,30 +102,110 @@ type logger struct {
 	zap    *zap.Logger
 	sugar  *zap.SugaredLogger
 	config *Config
+	level  *AtomicLevel
+	span   zapcore.LevelEnabler // 记录为 span 事件的级别，nil 表示不记录
+	res    *resources           // New 创建的输出等资源，由 Close 释放，NewFromCore 创建时为 nil
 }
 
-var (
-	globalLogger Logger
-	once         sync.Once
-)
+// resources 需要在 logger 关闭时释放的资源，With 等派生的 logger 共享同一份
+type resources struct {
+	once    sync.Once
+	closers []func() error
+	err     error
+}
 
-// Init 初始化全局日志
-func Init(cfg *Config) Logger {
-	once.Do(func() {
-		globalLogger = New(cfg)
+// close 依次释放所有资源，只执行一次
+func (r *resources) close() error {
+	if r == nil {
+		return nil
+	}
+	r.once.Do(func() {
+		errs := make([]error, 0, len(r.closers))
+		for _, fn := range r.closers {
+			errs = append(errs, fn())
+		}
+		r.err = errors.Join(errs...)
 	})
-	return globalLogger
+	return r.err
 }
 
-// New 创建新的日志实例
+// New 创建新的日志实例，返回的 Logger 实现 io.Closer，不再使用时调用 Close 释放日志文件等输出
 func New(cfg *Config) Logger {
 	if cfg == nil {
 		cfg = DefaultConfig()
 	}
 
-	// 解析日志级别
-	level := parseLevel(cfg.Level)
+	// 解析日志级别，运行时可通过 SetLevel 或 LevelHandler 修改
+	level := NewAtomicLevel(parseLevel(cfg.Level))
+
+	// 敏感字段脱敏规则，在每个输出编码前生效，不合法的模式会被跳过，其余规则照常生效
+	redact, err := newRedactor(cfg.Redact)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+	}
+
+	// 创建输出，每个输出有独立的格式、级别下限与字段过滤，无法创建的输出会被跳过
+	sinks := cfg.Sinks
+	if len(sinks) == 0 {
+		sinks = defaultSinks(cfg)
+	}
+	cores := make([]zapcore.Core, 0, len(sinks))
+	res := &resources{}
+	for _, sc := range sinks {
+		c, release, err := newSinkCore(sc, cfg, level)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v, sink skipped\n", err)
+			continue
+		}
+		cores = append(cores, c)
+		res.closers = append(res.closers, release)
+	}
+
+	// 创建核心，按配置加上采样与限流，脱敏位于最外层，对所有输出与 span 事件只处理一次
+	core, stop := newSampledCore(zapcore.NewTee(cores...), cfg.Sampling, redact)
+	if stop != nil {
+		// 先停止汇总，剩余的汇总写入后再释放输出
+		res.closers = append([]func() error{stop}, res.closers...)
+	}
+	if redact != nil {
+		core = &redactCore{Core: core, r: redact}
+	}
+
+	// 创建logger
+	zapLogger := newZap(core)
+
+	l := &logger{
+		zap:    zapLogger,
+		sugar:  zapLogger.Sugar(),
+		config: cfg,
+		level:  level,
+		res:    res,
+	}
+	if cfg.SpanEvents != "" {
+		l.span = parseLevel(cfg.SpanEvents)
+	}
+	return l
+}
+
+// NewFromCore 基于已有的 zapcore.Core 创建 Logger，调用位置与堆栈的记录方式与 New 一致
+//
+// 级别由 core 决定，返回的 Logger 不支持 SetLevel。
+func NewFromCore(core zapcore.Core) Logger {
+	z := newZap(core)
+	return &logger{zap: z, sugar: z.Sugar()}
+}
+
+// newZap 创建记录调用位置、Error 及以上级别记录堆栈的 zap.Logger
+func newZap(core zapcore.Core) *zap.Logger {
+	return zap.New(core,
+		zap.AddCaller(),
+		zap.AddCallerSkip(1),
+		zap.AddStacktrace(zapcore.ErrorLevel),
+	)
+}
 
+// newEncoder 按格式创建编码器
+func newEncoder(format string) zapcore.Encoder {
 	// 编码器配置
 	encoderConfig := zapcore.EncoderConfig{
 		TimeKey:        "time",
@@ -117,46 +222,10 @@ func New(cfg *Config) Logger {
 		EncodeCaller:   zapcore.ShortCallerEncoder,
 	}
 
-	// 创建编码器
-	var encoder zapcore.Encoder
-	if cfg.Format == "console" {
-		encoder = zapcore.NewConsoleEncoder(encoderConfig)
-	} else {
-		encoder = zapcore.NewJSONEncoder(encoderConfig)
-	}
-
-	// 创建输出
-	var writeSyncer zapcore.WriteSyncer
-	if cfg.Filename != "" {
-		writeSyncer = zapcore.NewMultiWriteSyncer(
-			zapcore.AddSync(os.Stdout),
-			zapcore.AddSync(&lumberjack.Logger{
-				Filename:   cfg.Filename,
-				MaxSize:    cfg.MaxSize,
-				MaxBackups: cfg.MaxBackups,
-				MaxAge:     cfg.MaxAge,
-				Compress:   cfg.Compress,
-			}),
-		)
-	} else {
-		writeSyncer = zapcore.AddSync(os.Stdout)
-	}
-
-	// 创建核心
-	core := zapcore.NewCore(encoder, writeSyncer, level)
-
-	// 创建logger
-	zapLogger := zap.New(core,
-		zap.AddCaller(),
-		zap.AddCallerSkip(1),
-		zap.AddStacktrace(zapcore.ErrorLevel),
-	)
-
-	return &logger{
-		zap:    zapLogger,
-		sugar:  zapLogger.Sugar(),
-		config: cfg,
+	if format == "console" {
+		return zapcore.NewConsoleEncoder(encoderConfig)
 	}
+	return zapcore.NewJSONEncoder(encoderConfig)
 }
 
 func parseLevel(level string) zapcore.Level {
@@ -196,53 +265,95 @@ func (l *logger) Fatal(msg string, fields ...Field) {
 	l.zap.Fatal(msg, fields...)
 }
 
+func (l *logger) Debugf(template string, args ...any) { l.sugar.Debugf(template, args...) }
+func (l *logger) Infof(template string, args ...any)  { l.sugar.Infof(template, args...) }
+func (l *logger) Warnf(template string, args ...any)  { l.sugar.Warnf(template, args...) }
+func (l *logger) Errorf(template string, args ...any) { l.sugar.Errorf(template, args...) }
+func (l *logger) Fatalf(template string, args ...any) { l.sugar.Fatalf(template, args...) }
+
+func (l *logger) Debugw(msg string, keysAndValues ...any) { l.sugar.Debugw(msg, keysAndValues...) }
+func (l *logger) Infow(msg string, keysAndValues ...any)  { l.sugar.Infow(msg, keysAndValues...) }
+func (l *logger) Warnw(msg string, keysAndValues ...any)  { l.sugar.Warnw(msg, keysAndValues...) }
+func (l *logger) Errorw(msg string, keysAndValues ...any) { l.sugar.Errorw(msg, keysAndValues...) }
+func (l *logger) Fatalw(msg string, keysAndValues ...any) { l.sugar.Fatalw(msg, keysAndValues...) }
+
+func (l *logger) Named(name string) Logger {
+	return l.derive(l.zap.Named(name))
+}
+
 func (l *logger) With(fields ...Field) Logger {
+	return l.derive(l.zap.With(fields...))
+}
+
+// derive 基于新的 zap.Logger 创建共享配置与级别的 logger
+func (l *logger) derive(z *zap.Logger) *logger {
 	return &logger{
-		zap:    l.zap.With(fields...),
-		sugar:  l.sugar.With(fields),
+		zap:    z,
+		sugar:  z.Sugar(),
 		config: l.config,
+		level:  l.level,
+		span:   l.span,
+		res:    l.res,
 	}
 }
 
-// WithContext 从context提取trace信息
+// WithContext 从context提取trace信息及已注册的上下文字段
 func (l *logger) WithContext(ctx context.Context) Logger {
 	if ctx == nil {
 		return l
 	}
-	// 从context中提取trace_id等信息
+	// 开启 span 事件时，后续日志同时记录到 ctx 中正在记录的 span 上
+	target := l
+	if span := trace.SpanFromContext(ctx); l.span != nil && span.IsRecording() {
+		target = l.derive(l.zap.WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+			return wrapRedacted(c, func(c zapcore.Core) zapcore.Core { return withSpan(c, span, l.span) })
+		})))
+	}
+
+	// 从context中提取trace_id、span_id等信息
 	fields := extractTraceFields(ctx)
 	if len(fields) > 0 {
-		return l.With(fields...)
+		return target.With(fields...)
 	}
-	return l
+	return target
+}
+
+// AtomicLevel 返回运行时级别，With 派生的 logger 共享同一个级别，由 NewFromCore 创建时为 nil
+func (l *logger) AtomicLevel() *AtomicLevel {
+	return l.level
 }
 
 func (l *logger) Sync() error {
 	return l.zap.Sync()
 }
 
-// extractTraceFields 从context提取追踪字段
-func extractTraceFields(ctx context.Context) []Field {
-	var fields []Field
-	// 预留trace_id等字段提取
-	if traceID := ctx.Value("trace_id"); traceID != nil {
-		if id, ok := traceID.(string); ok {
-			fields = append(fields, String("trace_id", id))
-		}
-	}
-	if spanID := ctx.Value("span_id"); spanID != nil {
-		if id, ok := spanID.(string); ok {
-			fields = append(fields, String("span_id", id))
-		}
-	}
-	return fields
+// Close 写出缓冲的日志并释放 New 创建的输出，实现 io.Closer
+//
+// 日志文件、异步写入器与网络连接在 logger 之间按写入目标共享，只有不再被任何 logger 使用时才会关闭。
+// With 等派生的 logger 与 l 共享输出，Close 之后都不应再使用。
+func (l *logger) Close() error {
+	_ = l.zap.Sync()
+	return l.res.close()
 }
 
-// 全局日志函数
-func Debug(msg string, fields ...Field) { globalLogger.Debug(msg, fields...) }
-func Info(msg string, fields ...Field)  { globalLogger.Info(msg, fields...) }
-func Warn(msg string, fields ...Field)  { globalLogger.Warn(msg, fields...) }
-func Error(msg string, fields ...Field) { globalLogger.Error(msg, fields...) }
-func Fatal(msg string, fields ...Field) { globalLogger.Fatal(msg, fields...) }
-func With(fields ...Field) Logger       { return globalLogger.With(fields...) }
-func Sync() error                       { return globalLogger.Sync() }
+// 全局日志函数，Init 之前写入默认的全局日志，见 L
+func Debug(msg string, fields ...Field) { global.Load().caller.Debug(msg, fields...) }
+func Info(msg string, fields ...Field)  { global.Load().caller.Info(msg, fields...) }
+func Warn(msg string, fields ...Field)  { global.Load().caller.Warn(msg, fields...) }
+func Error(msg string, fields ...Field) { global.Load().caller.Error(msg, fields...) }
+func Fatal(msg string, fields ...Field) { global.Load().caller.Fatal(msg, fields...) }
+func With(fields ...Field) Logger       { return L().With(fields...) }
+func Named(name string) Logger          { return L().Named(name) }
+func Sync() error                       { return L().Sync() }
+
+func Debugf(template string, args ...any) { global.Load().caller.Debugf(template, args...) }
+func Infof(template string, args ...any)  { global.Load().caller.Infof(template, args...) }
+func Warnf(template string, args ...any)  { global.Load().caller.Warnf(template, args...) }
+func Errorf(template string, args ...any) { global.Load().caller.Errorf(template, args...) }
+func Fatalf(template string, args ...any) { global.Load().caller.Fatalf(template, args...) }
+
+func Debugw(msg string, keysAndValues ...any) { global.Load().caller.Debugw(msg, keysAndValues...) }
+func Infow(msg string, keysAndValues ...any)  { global.Load().caller.Infow(msg, keysAndValues...) }
+func Warnw(msg string, keysAndValues ...any)  { global.Load().caller.Warnw(msg, keysAndValues...) }
+func Errorw(msg string, keysAndValues ...any) { global.Load().caller.Errorw(msg, keysAndValues...) }
+func Fatalw(msg string, keysAndValues ...any) { global.Load().caller.Fatalw(msg, keysAndValues...) }
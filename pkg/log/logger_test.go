@@ -0,0 +1,73 @@
+package log
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// here 返回调用方所在的行号
+func here() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line
+}
+
+func TestSugaredMethodsCaller(t *testing.T) {
+	obs, logs := observer.New(DebugLevel)
+	l := NewFromCore(obs)
+	defer ReplaceGlobal(l)()
+	sl := NewFromSlog(NewSlogHandler(l))
+
+	tests := []struct {
+		name string
+		log  func() int // 记录日志并返回所在行号
+	}{
+		{"Info", func() int { l.Info("msg"); return here() }},
+		{"Debugf", func() int { l.Debugf("msg %d", 1); return here() }},
+		{"Infof", func() int { l.Infof("msg %d", 1); return here() }},
+		{"Warnf", func() int { l.Warnf("msg %d", 1); return here() }},
+		{"Errorf", func() int { l.Errorf("msg %d", 1); return here() }},
+		{"Debugw", func() int { l.Debugw("msg", "k", 1); return here() }},
+		{"Infow", func() int { l.Infow("msg", "k", 1); return here() }},
+		{"Warnw", func() int { l.Warnw("msg", "k", 1); return here() }},
+		{"Errorw", func() int { l.Errorw("msg", "k", 1); return here() }},
+		{"Named.Infof", func() int { l.Named("a").Infof("msg"); return here() }},
+		{"global Infof", func() int { Infof("msg %d", 1); return here() }},
+		{"global Infow", func() int { Infow("msg", "k", 1); return here() }},
+		{"slog Infof", func() int { sl.Infof("msg %d", 1); return here() }},
+		{"slog Warnw", func() int { sl.Warnw("msg", "k", 1); return here() }},
+		{"slog Named.Error", func() int { sl.Named("a").Error("msg"); return here() }},
+	}
+	for _, tt := range tests {
+		want := tt.log()
+		all := logs.TakeAll()
+		if len(all) != 1 {
+			t.Fatalf("%s: %d entries, want 1", tt.name, len(all))
+		}
+		c := all[0].Caller
+		if !c.Defined || filepath.Base(c.File) != "logger_test.go" || c.Line != want {
+			t.Errorf("%s: caller = %s, want logger_test.go:%d", tt.name, c.TrimmedPath(), want)
+		}
+	}
+}
+
+func TestNamedChaining(t *testing.T) {
+	obs, logs := observer.New(DebugLevel)
+	l := NewFromCore(obs)
+
+	l.Named("orders").Named("db").Infow("query", "rows", 3)
+	l.Named("orders").Named("").Info("empty")
+
+	all := logs.All()
+	if all[0].LoggerName != "orders.db" {
+		t.Errorf("name = %q, want orders.db", all[0].LoggerName)
+	}
+	if m := all[0].ContextMap(); m["rows"] != int64(3) {
+		t.Errorf("fields = %v, want rows=3", m)
+	}
+	if all[1].LoggerName != "orders" {
+		t.Errorf("name = %q, want orders", all[1].LoggerName)
+	}
+}
@@ -0,0 +1,51 @@
+// Package logtest 提供用于单元测试的 log.Logger
+//
+// New 返回的 Logger 将日志记录在内存中，便于断言：
+//
+//	logger, rec := logtest.New()
+//	svc := NewService(logger)
+//	svc.Do()
+//	if rec.FilterMessage("retrying").Len() != 3 { ... }
+//
+// NewTB 返回的 Logger 通过 t.Log 输出，日志只在测试失败或 -v 时显示，并与对应的测试关联。
+package logtest
+
+import (
+	"testing"
+
+	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/wufashanchu/gostrap/pkg/log"
+)
+
+// Recorder 记录的日志，并发安全，支持按级别、消息和字段过滤：
+//
+//	rec.FilterLevelExact(log.ErrorLevel).Len()
+//	rec.FilterMessage("x").Len()
+//	rec.FilterField(log.String("user", "u1")).All()
+type Recorder = observer.ObservedLogs
+
+// Entry 一条记录的日志，包含级别、消息以及所有字段(含 With 追加的字段)
+type Entry = observer.LoggedEntry
+
+// New 创建记录全部级别日志的 Logger
+func New() (log.Logger, *Recorder) {
+	return NewAt(log.DebugLevel)
+}
+
+// NewAt 创建只记录不低于 level 的日志的 Logger
+func NewAt(level log.Level) (log.Logger, *Recorder) {
+	core, rec := observer.New(level)
+	return log.NewFromCore(core), rec
+}
+
+// NewTB 创建通过 t.Log 输出的 Logger，输出全部级别的日志
+func NewTB(t testing.TB) log.Logger {
+	return NewTBAt(t, log.DebugLevel)
+}
+
+// NewTBAt 创建通过 t.Log 输出不低于 level 的日志的 Logger
+func NewTBAt(t testing.TB, level log.Level) log.Logger {
+	return log.NewFromCore(zaptest.NewLogger(t, zaptest.Level(level)).Core())
+}
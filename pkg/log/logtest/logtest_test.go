@@ -0,0 +1,60 @@
+package logtest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/wufashanchu/gostrap/pkg/log"
+)
+
+func TestNew(t *testing.T) {
+	logger, rec := New()
+	logger.With(log.String("user", "u1")).Infow("login", "attempt", 2)
+	logger.Named("svc").Errorf("retry %d", 3)
+
+	if rec.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", rec.Len())
+	}
+	if n := rec.FilterField(log.String("user", "u1")).FilterMessage("login").Len(); n != 1 {
+		t.Errorf("login entries with user field = %d, want 1", n)
+	}
+	e := rec.FilterLevelExact(log.ErrorLevel).All()
+	if len(e) != 1 || e[0].Message != "retry 3" || e[0].LoggerName != "svc" {
+		t.Errorf("error entries = %+v", e)
+	}
+}
+
+func TestNewAt(t *testing.T) {
+	logger, rec := NewAt(log.WarnLevel)
+	logger.Info("dropped")
+	logger.Warn("kept")
+	if rec.Len() != 1 || rec.All()[0].Message != "kept" {
+		t.Errorf("entries = %+v, want only kept", rec.All())
+	}
+}
+
+// recordTB 记录 Logf 的输出
+type recordTB struct {
+	testing.TB
+	mu    sync.Mutex
+	lines []string
+}
+
+func (r *recordTB) Logf(format string, args ...any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lines = append(r.lines, fmt.Sprintf(format, args...))
+}
+
+func TestNewTBAt(t *testing.T) {
+	tb := &recordTB{TB: t}
+	logger := NewTBAt(tb, log.InfoLevel)
+	logger.Debug("hidden")
+	logger.Info("shown", log.Int("n", 1))
+
+	if len(tb.lines) != 1 || !strings.Contains(tb.lines[0], "shown") || !strings.Contains(tb.lines[0], `"n": 1`) {
+		t.Errorf("lines = %q, want only the info entry", tb.lines)
+	}
+}
@@ -0,0 +1,43 @@
+package log
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// 日志组件的 Prometheus 指标，需通过 Collectors 注册后才会导出
+var (
+	asyncDroppedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "gostrap",
+			Subsystem: "log",
+			Name:      "async_dropped_total",
+			Help:      "Total number of log lines dropped by the async writer because its queue was full",
+		},
+		[]string{"policy"},
+	)
+
+	rotationsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "gostrap",
+			Subsystem: "log",
+			Name:      "rotations_total",
+			Help:      "Total number of log file rotations and reopens",
+		},
+		[]string{"reason"},
+	)
+
+	rotateErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "gostrap",
+			Subsystem: "log",
+			Name:      "rotate_errors_total",
+			Help:      "Total number of errors while opening, rotating, compressing or cleaning up log files",
+		},
+		[]string{"op"},
+	)
+)
+
+// Collectors 返回日志组件的指标，可注册到 metrics.Metrics 的 Registry 中
+//
+//	m.Registry().MustRegister(log.Collectors()...)
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{asyncDroppedTotal, rotationsTotal, rotateErrorsTotal}
+}
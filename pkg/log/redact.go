@@ -0,0 +1,340 @@
+package log
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// 脱敏方式
+const (
+	RedactMask = "mask" // 替换为 ******
+	RedactHash = "hash" // 替换为 SHA-256 摘要前缀，相同的值得到相同的结果，便于关联排查
+	RedactDrop = "drop" // 删除整个字段
+)
+
+// 常用的值匹配规则
+const (
+	RedactCardNumber = `\b(?:\d[ -]?){12,18}\d\b`
+	RedactEmail      = `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`
+)
+
+// redactedValue 脱敏后的占位符
+const redactedValue = "******"
+
+// RedactRule 脱敏规则，字段名或值匹配时按 Action 处理
+//
+//	redact:
+//	  - fields: [password, "*token*", authorization]
+//	  - values: ['\b(?:\d[ -]?){12,18}\d\b']
+//	    action: hash
+type RedactRule struct {
+	Fields []string `json:"fields" yaml:"fields" mapstructure:"fields"` // 字段名模式，不区分大小写，支持 * 通配，如 *token*
+	Values []string `json:"values" yaml:"values" mapstructure:"values"` // 值的正则表达式，同时作用于日志消息，只处理匹配的部分，drop 时删除整个字段(消息中替换为占位符)
+	Action string   `json:"action" yaml:"action" mapstructure:"action"` // 脱敏方式: mask, hash, drop，默认 mask
+}
+
+// redactor 编译后的脱敏规则
+type redactor struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	fields []string
+	values []*regexp.Regexp
+	action string
+}
+
+// newRedactor 编译脱敏规则，没有规则时返回 nil
+//
+// 规则中不合法的字段模式或正则表达式会被跳过并在 error 中报告，同一规则中的其他模式以及其他规则仍然生效；
+// 未知的 Action 按 mask 处理，确保配置错误时不会输出明文。
+func newRedactor(rules []RedactRule) (*redactor, error) {
+	var r redactor
+	var errs []error
+	for i, rule := range rules {
+		cr := compiledRule{action: rule.Action}
+		switch cr.action {
+		case "":
+			cr.action = RedactMask
+		case RedactMask, RedactHash, RedactDrop:
+		default:
+			errs = append(errs, fmt.Errorf("log: redact rule #%d: unknown action %q, using %s", i, rule.Action, RedactMask))
+			cr.action = RedactMask
+		}
+		for _, f := range rule.Fields {
+			if _, err := path.Match(f, ""); err != nil {
+				errs = append(errs, fmt.Errorf("log: redact rule #%d: invalid field pattern %q skipped: %w", i, f, err))
+				continue
+			}
+			cr.fields = append(cr.fields, strings.ToLower(f))
+		}
+		for _, v := range rule.Values {
+			re, err := regexp.Compile(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("log: redact rule #%d: invalid value pattern skipped: %w", i, err))
+				continue
+			}
+			cr.values = append(cr.values, re)
+		}
+		if len(cr.fields) > 0 || len(cr.values) > 0 {
+			r.rules = append(r.rules, cr)
+		}
+	}
+	if len(r.rules) == 0 {
+		return nil, errors.Join(errs...)
+	}
+	return &r, errors.Join(errs...)
+}
+
+// keyAction 返回字段名匹配的规则的脱敏方式
+func (r *redactor) keyAction(key string) (string, bool) {
+	key = strings.ToLower(key)
+	for _, rule := range r.rules {
+		for _, p := range rule.fields {
+			if ok, _ := path.Match(p, key); ok {
+				return rule.action, true
+			}
+		}
+	}
+	return "", false
+}
+
+// redactString 按值规则处理字符串，drop 规则匹配时返回 false
+func (r *redactor) redactString(s string) (string, bool) {
+	for _, rule := range r.rules {
+		for _, re := range rule.values {
+			if !re.MatchString(s) {
+				continue
+			}
+			if rule.action == RedactDrop {
+				return "", false
+			}
+			s = re.ReplaceAllStringFunc(s, func(m string) string { return apply(rule.action, m) })
+		}
+	}
+	return s, true
+}
+
+// apply 按脱敏方式替换值
+func apply(action string, v any) string {
+	if action == RedactHash {
+		sum := sha256.Sum256(fmt.Append(nil, v))
+		return "sha256:" + hex.EncodeToString(sum[:8])
+	}
+	return redactedValue
+}
+
+// redactFields 处理字段列表，返回新的切片
+func (r *redactor) redactFields(fields []zapcore.Field) []zapcore.Field {
+	out := make([]zapcore.Field, 0, len(fields))
+	for _, f := range fields {
+		if f, ok := r.redactField(f); ok {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// redactField 处理单个字段，字段需被删除时返回 false
+func (r *redactor) redactField(f zapcore.Field) (zapcore.Field, bool) {
+	if action, ok := r.keyAction(f.Key); ok {
+		if action == RedactDrop {
+			return f, false
+		}
+		return zap.String(f.Key, apply(action, fieldValue(f))), true
+	}
+
+	switch f.Type {
+	case zapcore.StringType:
+		s, ok := r.redactString(f.String)
+		return zap.String(f.Key, s), ok
+	case zapcore.StringerType, zapcore.ErrorType, zapcore.ByteStringType:
+		s, ok := r.redactString(fmt.Sprint(fieldValue(f)))
+		return zap.String(f.Key, s), ok
+	case zapcore.ReflectType, zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType:
+		// 嵌套对象先编码为 map/slice，再逐层处理
+		v, ok := r.redactValue(fieldValue(f))
+		return zap.Any(f.Key, v), ok
+	}
+	return f, true
+}
+
+// redactValue 递归处理编码后的嵌套值
+func (r *redactor) redactValue(v any) (any, bool) {
+	switch v := v.(type) {
+	case string:
+		return r.redactString(v)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			if action, ok := r.keyAction(k); ok {
+				if action != RedactDrop {
+					out[k] = apply(action, item)
+				}
+				continue
+			}
+			if item, ok := r.redactValue(item); ok {
+				out[k] = item
+			}
+		}
+		return out, true
+	case []any:
+		out := make([]any, 0, len(v))
+		for _, item := range v {
+			if item, ok := r.redactValue(item); ok {
+				out = append(out, item)
+			}
+		}
+		return out, true
+	}
+	return v, true
+}
+
+// fieldValue 返回字段编码后的值，对象会被编码为 map[string]any
+func fieldValue(f zapcore.Field) any {
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+	if v, ok := enc.Fields[f.Key]; ok {
+		return normalize(v)
+	}
+	return nil
+}
+
+// normalize 将编码结果中的 zapcore 内部类型转换为普通的 map 与 slice，对象通过 JSON 往返编码
+func normalize(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			v[k] = normalize(item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = normalize(item)
+		}
+		return v
+	case []byte:
+		return string(v)
+	}
+	return jsonValue(v)
+}
+
+// redactCore 在写入前按规则处理字段与日志消息，With 追加的字段同样会被处理
+//
+// redactCore 位于 logger 的 core 链最外层，输出与 span 事件收到的都是脱敏后的字段，每条日志只脱敏一次。
+// Check 时先由内层 core 决定写入哪些输出，保留各输出独立的级别下限以及采样与限流的判断。
+type redactCore struct {
+	zapcore.Core
+	r *redactor
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.r.redactFields(fields)), r: c.r}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	inner := c.Core.Check(ent, nil)
+	if inner == nil {
+		return ce
+	}
+	w := &redactWrite{ce: inner, r: c.r}
+	w.outer = ce.AddCore(ent, w)
+	return w.outer
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	inner := c.Core.Check(ent, nil)
+	if inner == nil {
+		return nil
+	}
+	var out errorOutput
+	inner.Entry = c.r.redactEntry(ent)
+	inner.ErrorOutput = &out
+	inner.Write(c.r.redactFields(fields)...)
+	return out.err
+}
+
+// redactWrite 由 redactCore.Check 添加，脱敏后写入内层 core 已通过检查的输出
+type redactWrite struct {
+	ce    *zapcore.CheckedEntry // 内层 core 的检查结果
+	outer *zapcore.CheckedEntry // 包含 redactWrite 的检查结果，写入时从中取得 logger 的 ErrorOutput
+	r     *redactor
+}
+
+func (w *redactWrite) Enabled(zapcore.Level) bool        { return true }
+func (w *redactWrite) With([]zapcore.Field) zapcore.Core { return w }
+func (w *redactWrite) Sync() error                       { return nil }
+func (w *redactWrite) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(ent, w)
+}
+
+func (w *redactWrite) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	// 调用位置与堆栈在 Check 之后才由 zap 填入，使用写入时传入的 entry
+	w.ce.Entry = w.r.redactEntry(ent)
+	w.ce.ErrorOutput = w.outer.ErrorOutput
+	w.ce.Write(w.r.redactFields(fields)...)
+	return nil
+}
+
+// redactEntry 按值规则处理日志消息，drop 规则匹配的部分同样替换为占位符
+func (r *redactor) redactEntry(ent zapcore.Entry) zapcore.Entry {
+	for _, rule := range r.rules {
+		for _, re := range rule.values {
+			ent.Message = re.ReplaceAllStringFunc(ent.Message, func(m string) string {
+				if rule.action == RedactDrop {
+					return redactedValue
+				}
+				return apply(rule.action, m)
+			})
+		}
+	}
+	return ent
+}
+
+// errorOutput 记录内层 core 的写入错误，由 redactCore.Write 返回给调用方
+type errorOutput struct {
+	err error
+}
+
+func (o *errorOutput) Write(p []byte) (int, error) {
+	o.err = errors.Join(o.err, errors.New(strings.TrimSpace(string(p))))
+	return len(p), nil
+}
+
+func (o *errorOutput) Sync() error { return nil }
+
+// wrapRedacted 在 redactCore 之内包装 core，使 wrap 添加的 core(如 span 事件)同样只收到脱敏后的字段
+func wrapRedacted(core zapcore.Core, wrap func(zapcore.Core) zapcore.Core) zapcore.Core {
+	if rc, ok := core.(*redactCore); ok {
+		return &redactCore{Core: wrap(rc.Core), r: rc.r}
+	}
+	return wrap(core)
+}
+
+// jsonValue 将结构体等反射值通过 JSON 往返转换为 map[string]any 或 []any，基本类型原样返回
+func jsonValue(v any) any {
+	switch v.(type) {
+	case nil, string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr,
+		float32, float64, complex64, complex128, time.Time, time.Duration:
+		return v
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	var out any
+	if err := json.Unmarshal(b, &out); err != nil {
+		return string(b)
+	}
+	return out
+}
@@ -0,0 +1,83 @@
+package log
+
+import (
+	"context"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNewRedactorSkipsOnlyInvalidPatterns(t *testing.T) {
+	r, err := newRedactor([]RedactRule{
+		{Fields: []string{"password"}},
+		{Values: []string{"(", RedactEmail}, Action: "bogus"},
+	})
+	if err == nil {
+		t.Fatal("expected an error for the invalid pattern and action")
+	}
+	if r == nil {
+		t.Fatal("valid rules were discarded")
+	}
+
+	fields := r.redactFields([]zapcore.Field{
+		String("password", "hunter2"),
+		String("note", "mail a@example.com"),
+	})
+	if got := fields[0].String; got != redactedValue {
+		t.Errorf("password = %q, want %q", got, redactedValue)
+	}
+	if got := fields[1].String; got != "mail "+redactedValue {
+		t.Errorf("note = %q, want the email masked", got)
+	}
+}
+
+func TestRedactCoreKeepsSinkLevelsAndSpanEvents(t *testing.T) {
+	r, err := newRedactor([]RedactRule{{Fields: []string{"password"}, Values: []string{RedactCardNumber}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, allLogs := observer.New(DebugLevel)
+	errOnly, errLogs := observer.New(ErrorLevel)
+
+	rec := tracetest.NewSpanRecorder()
+	ctx, span := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)).Tracer("test").Start(context.Background(), "op")
+
+	l := NewFromCore(&redactCore{Core: zapcore.NewTee(all, errOnly), r: r}).(*logger)
+	l.span = WarnLevel
+	cl := l.WithContext(ctx).With(String("password", "with-secret"))
+	cl.Info("info", String("password", "hunter2"))
+	cl.Warn("warn", String("password", "hunter2"))
+	cl.Errorf("card %s", "4111 1111 1111 1111")
+	span.End()
+
+	if allLogs.Len() != 3 || errLogs.Len() != 1 {
+		t.Fatalf("sink entries = %d and %d, want 3 and 1", allLogs.Len(), errLogs.Len())
+	}
+	e := errLogs.All()[0]
+	if !e.Caller.Defined || e.Stack == "" {
+		t.Errorf("error entry caller defined = %v, stack = %q, want both recorded", e.Caller.Defined, e.Stack)
+	}
+	if e.Message != "card "+redactedValue {
+		t.Errorf("message = %q, want the card number masked", e.Message)
+	}
+	for _, e := range allLogs.All() {
+		for _, f := range e.Context {
+			if f.Key == "password" && f.String != redactedValue {
+				t.Errorf("%s: password = %q in sink", e.Message, f.String)
+			}
+		}
+	}
+
+	events := rec.Ended()[0].Events()
+	if len(events) != 2 {
+		t.Fatalf("span events = %d, want 2", len(events))
+	}
+	for _, a := range events[0].Attributes {
+		if a.Key == "password" && a.Value.AsString() != redactedValue {
+			t.Errorf("password = %q in span event", a.Value.AsString())
+		}
+	}
+}
@@ -0,0 +1,624 @@
+package log
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 按时间切割的周期
+const (
+	RotateDaily  = "daily"  // 每天切割，备份文件名如 app-2006-01-02.log
+	RotateHourly = "hourly" // 每小时切割，备份文件名如 app-2006-01-02T15.log
+)
+
+// 备份文件名中的时间格式，只按大小切割时使用切割时刻
+const (
+	dailyLayout  = "2006-01-02"
+	hourlyLayout = "2006-01-02T15"
+	sizeLayout   = "2006-01-02T15-04-05"
+)
+
+// 切割原因
+const (
+	RotateReasonSize   = "size"   // 文件达到 MaxSize
+	RotateReasonTime   = "time"   // 进入新的切割周期
+	RotateReasonSignal = "signal" // 收到信号或调用 ReopenFiles
+)
+
+// ErrRotateClosed 切割写入器关闭后继续写入时返回
+var ErrRotateClosed = errors.New("log: rotate writer closed")
+
+// RotateConfig 日志文件的切割配置
+type RotateConfig struct {
+	Filename   string // 日志文件路径
+	Rotate     string // 按时间切割的周期: daily, hourly，为空时只按大小切割
+	MaxSize    int    // 单文件最大大小(MB)，为 0 时使用默认的 100MB，小于 0 时不按大小切割
+	MaxBackups int    // 最大备份数，为 0 时不限制
+	MaxAge     int    // 最大保留天数，为 0 时不限制
+	Compress   bool   // 是否以 gzip 压缩备份文件
+}
+
+// RotateEvent 一次切割的结果
+type RotateEvent struct {
+	Filename string    // 日志文件路径
+	Backup   string    // 切割出的备份文件路径，开启压缩时为压缩后的 .gz 文件
+	Reason   string    // 切割原因: size, time, signal
+	Time     time.Time // 切割时刻
+}
+
+// RotateHook 切割完成后的回调，在后台协程中于压缩之后调用，可用于上传或校验备份文件
+type RotateHook func(ev RotateEvent)
+
+var (
+	rotateHooksMu sync.RWMutex
+	rotateHooks   []RotateHook
+)
+
+// RegisterRotateHook 注册切割完成后的回调，按注册顺序调用
+//
+//	log.RegisterRotateHook(func(ev log.RotateEvent) {
+//		uploader.Enqueue(ev.Backup)
+//	})
+func RegisterRotateHook(hooks ...RotateHook) {
+	rotateHooksMu.Lock()
+	defer rotateHooksMu.Unlock()
+	rotateHooks = append(rotateHooks, hooks...)
+}
+
+// RotateWriter 按大小与时间切割的日志文件
+//
+// 切割时当前文件被重命名为带日期的备份文件，如 app.log 切割为 app-2006-01-02.log，
+// 同一周期内多次切割时依次追加序号，如 app-2006-01-02.1.log。
+// 压缩、清理过期备份与 RotateHook 均在后台协程中执行，不阻塞写入。
+type RotateWriter struct {
+	cfg     RotateConfig
+	maxSize int64
+	layout  string
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	start  time.Time // 当前文件所属周期的开始时刻
+	next   time.Time // 下次按时间切割的时刻，只按大小切割时为零值
+	closed bool
+
+	pendingMu sync.Mutex
+	pending   []RotateEvent
+	wake      chan struct{}
+	done      chan struct{}
+	stopped   chan struct{}
+	closing   sync.Once
+}
+
+// NewRotateWriter 创建切割写入器并启动后台协程，文件在首次写入时打开，不再使用时需调用 Close
+func NewRotateWriter(cfg RotateConfig) (*RotateWriter, error) {
+	if cfg.Filename == "" {
+		return nil, fmt.Errorf("log: rotate writer requires filename")
+	}
+	layout, err := rotateLayout(cfg.Rotate)
+	if err != nil {
+		return nil, err
+	}
+	w := &RotateWriter{
+		cfg:     cfg,
+		maxSize: maxSizeBytes(cfg.MaxSize),
+		layout:  layout,
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	registerRotateWriter(w)
+	go w.run()
+	return w, nil
+}
+
+// defaultMaxSize MaxSize 为 0 时单文件的最大大小(MB)
+const defaultMaxSize = 100
+
+// maxSizeBytes 将 MaxSize 换算为字节数，返回 0 表示不按大小切割
+func maxSizeBytes(mb int) int64 {
+	switch {
+	case mb == 0:
+		mb = defaultMaxSize
+	case mb < 0:
+		return 0
+	}
+	return int64(mb) * 1024 * 1024
+}
+
+// rotateLayout 返回切割周期对应的备份文件名时间格式
+func rotateLayout(period string) (string, error) {
+	switch period {
+	case RotateDaily:
+		return dailyLayout, nil
+	case RotateHourly:
+		return hourlyLayout, nil
+	case "":
+		return sizeLayout, nil
+	}
+	return "", fmt.Errorf("log: unknown rotate period %q", period)
+}
+
+// setConfig 更新切割配置，Filename 不变，新的周期与大小限制从下一次写入开始生效
+func (w *RotateWriter) setConfig(cfg RotateConfig) error {
+	layout, err := rotateLayout(cfg.Rotate)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	// Filename 创建后不再修改，后台协程可以直接读取
+	w.cfg.Rotate, w.cfg.MaxSize, w.cfg.Compress = cfg.Rotate, cfg.MaxSize, cfg.Compress
+	w.cfg.MaxBackups, w.cfg.MaxAge = cfg.MaxBackups, cfg.MaxAge
+	w.layout = layout
+	w.maxSize = maxSizeBytes(cfg.MaxSize)
+	if w.file != nil {
+		w.start, w.next = w.period(w.start)
+	}
+	return nil
+}
+
+func (w *RotateWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, ErrRotateClosed
+	}
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	now := time.Now()
+	reason := ""
+	switch {
+	case !w.next.IsZero() && !now.Before(w.next):
+		reason = RotateReasonTime
+	case w.maxSize > 0 && w.size+int64(len(p)) > w.maxSize:
+		reason = RotateReasonSize
+	}
+	if reason != "" {
+		if err := w.rotate(reason, now); err != nil {
+			if w.file == nil {
+				return 0, err
+			}
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *RotateWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Sync()
+}
+
+// Rotate 立即切割当前文件
+func (w *RotateWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return ErrRotateClosed
+	}
+	if w.file == nil {
+		return w.open()
+	}
+	return w.rotate(RotateReasonSignal, time.Now())
+}
+
+// Reopen 配合外部的 logrotate 使用：文件已被移走时重新打开 Filename，否则与 Rotate 相同
+func (w *RotateWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return ErrRotateClosed
+	}
+	if w.file == nil {
+		return w.open()
+	}
+
+	cur, err := w.file.Stat()
+	if err != nil {
+		return w.reopen()
+	}
+	if fi, err := os.Stat(w.cfg.Filename); err != nil || !os.SameFile(cur, fi) {
+		return w.reopen()
+	}
+	return w.rotate(RotateReasonSignal, time.Now())
+}
+
+// Close 关闭文件，并等待已切割的备份完成压缩、清理与回调
+func (w *RotateWriter) Close() error {
+	w.mu.Lock()
+	var err error
+	if !w.closed {
+		w.closed = true
+		if w.file != nil {
+			err = w.file.Close()
+			w.file = nil
+		}
+	}
+	w.mu.Unlock()
+
+	unregisterRotateWriter(w)
+	w.closing.Do(func() { close(w.done) })
+	<-w.stopped
+	return err
+}
+
+// open 打开或创建 Filename，已有内容时以其修改时间判断所属周期
+func (w *RotateWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.cfg.Filename), 0o755); err != nil {
+		rotateErrorsTotal.WithLabelValues("open").Inc()
+		return fmt.Errorf("log: create log dir: %w", err)
+	}
+	f, err := os.OpenFile(w.cfg.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		rotateErrorsTotal.WithLabelValues("open").Inc()
+		return fmt.Errorf("log: open log file: %w", err)
+	}
+	w.file = f
+	w.size = 0
+	start := time.Now()
+	if fi, err := f.Stat(); err == nil && fi.Size() > 0 {
+		w.size = fi.Size()
+		start = fi.ModTime()
+	}
+	w.start, w.next = w.period(start)
+	return nil
+}
+
+// reopen 关闭当前文件后重新打开 Filename
+func (w *RotateWriter) reopen() error {
+	_ = w.file.Close()
+	w.file = nil
+	if err := w.open(); err != nil {
+		return err
+	}
+	rotationsTotal.WithLabelValues("reopen").Inc()
+	return nil
+}
+
+// rotate 将当前文件重命名为备份文件并打开新文件，备份交由后台协程处理
+//
+// 当前文件为空时只进入新的周期；重命名失败时继续写入原文件，避免丢失日志。
+func (w *RotateWriter) rotate(reason string, now time.Time) error {
+	if w.size == 0 {
+		w.start, w.next = w.period(now)
+		return nil
+	}
+
+	stamp := now
+	if w.cfg.Rotate != "" {
+		stamp = w.start
+	}
+	backup := w.backupName(stamp)
+
+	_ = w.file.Close()
+	w.file = nil
+	renameErr := os.Rename(w.cfg.Filename, backup)
+	if err := w.open(); err != nil {
+		return err
+	}
+	w.start, w.next = w.period(now)
+	if renameErr != nil {
+		rotateErrorsTotal.WithLabelValues("rename").Inc()
+		return fmt.Errorf("log: rotate log file: %w", renameErr)
+	}
+	rotationsTotal.WithLabelValues(reason).Inc()
+
+	w.pendingMu.Lock()
+	w.pending = append(w.pending, RotateEvent{Filename: w.cfg.Filename, Backup: backup, Reason: reason, Time: now})
+	w.pendingMu.Unlock()
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// period 返回 t 所在切割周期的开始时刻与下个周期的开始时刻
+func (w *RotateWriter) period(t time.Time) (start, next time.Time) {
+	switch w.cfg.Rotate {
+	case RotateDaily:
+		start = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		return start, start.AddDate(0, 0, 1)
+	case RotateHourly:
+		start = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+		return start, start.Add(time.Hour)
+	}
+	return t, time.Time{}
+}
+
+// backupName 返回未被占用的备份文件名
+func (w *RotateWriter) backupName(t time.Time) string {
+	prefix, ext := w.split()
+	stamp := t.Format(w.layout)
+	for i := 0; ; i++ {
+		name := prefix + stamp + ext
+		if i > 0 {
+			name = prefix + stamp + "." + strconv.Itoa(i) + ext
+		}
+		if !exists(name) && !exists(name+".gz") {
+			return name
+		}
+	}
+}
+
+// split 返回备份文件名的前缀与扩展名，如 logs/app.log 返回 logs/app- 与 .log
+func (w *RotateWriter) split() (prefix, ext string) {
+	ext = filepath.Ext(w.cfg.Filename)
+	return strings.TrimSuffix(w.cfg.Filename, ext) + "-", ext
+}
+
+func exists(name string) bool {
+	_, err := os.Lstat(name)
+	return err == nil
+}
+
+// run 后台协程，处理切割出的备份
+func (w *RotateWriter) run() {
+	defer close(w.stopped)
+	for {
+		select {
+		case <-w.wake:
+			w.process()
+		case <-w.done:
+			w.process()
+			return
+		}
+	}
+}
+
+// process 压缩备份、调用回调并清理过期备份
+func (w *RotateWriter) process() {
+	w.pendingMu.Lock()
+	events := w.pending
+	w.pending = nil
+	w.pendingMu.Unlock()
+	if len(events) == 0 {
+		return
+	}
+
+	// 配置可能被 setConfig 修改，持有锁取得副本
+	w.mu.Lock()
+	cfg := w.cfg
+	w.mu.Unlock()
+
+	for _, ev := range events {
+		if cfg.Compress {
+			if err := compressFile(ev.Backup); err != nil {
+				rotateErrorsTotal.WithLabelValues("compress").Inc()
+				fmt.Fprintf(os.Stderr, "log: compress %s: %v\n", ev.Backup, err)
+			} else {
+				ev.Backup += ".gz"
+			}
+		}
+		callRotateHooks(ev)
+	}
+	if err := w.cleanup(cfg); err != nil {
+		rotateErrorsTotal.WithLabelValues("cleanup").Inc()
+		fmt.Fprintf(os.Stderr, "log: remove old log files: %v\n", err)
+	}
+}
+
+func callRotateHooks(ev RotateEvent) {
+	rotateHooksMu.RLock()
+	hooks := rotateHooks
+	rotateHooksMu.RUnlock()
+	for _, h := range hooks {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					fmt.Fprintf(os.Stderr, "log: rotate hook panic: %v\n", r)
+				}
+			}()
+			h(ev)
+		}()
+	}
+}
+
+// compressFile 将 name 压缩为 name.gz 后删除 name
+func compressFile(name string) (err error) {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(name + ".gz")
+		}
+	}()
+
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	_ = src.Close()
+	return os.Remove(name)
+}
+
+// cleanup 按 MaxBackups 与 MaxAge 删除旧的备份文件，以修改时间排序
+func (w *RotateWriter) cleanup(cfg RotateConfig) error {
+	if cfg.MaxBackups <= 0 && cfg.MaxAge <= 0 {
+		return nil
+	}
+	backups, err := w.backups()
+	if err != nil {
+		return err
+	}
+
+	var remove []string
+	cutoff := time.Now().AddDate(0, 0, -cfg.MaxAge)
+	for i, b := range backups {
+		if (cfg.MaxBackups > 0 && i >= cfg.MaxBackups) || (cfg.MaxAge > 0 && b.mod.Before(cutoff)) {
+			remove = append(remove, b.name)
+		}
+	}
+	var errs []error
+	for _, name := range remove {
+		if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type backupFile struct {
+	name string
+	mod  time.Time
+}
+
+// backups 返回当前文件的所有备份，按修改时间从新到旧排序
+func (w *RotateWriter) backups() ([]backupFile, error) {
+	prefix, ext := w.split()
+	entries, err := os.ReadDir(filepath.Dir(w.cfg.Filename))
+	if err != nil {
+		return nil, err
+	}
+	base := filepath.Base(prefix)
+
+	var out []backupFile
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, base) {
+			continue
+		}
+		stamp := strings.TrimPrefix(name, base)
+		stamp = strings.TrimSuffix(stamp, ".gz")
+		if !strings.HasSuffix(stamp, ext) {
+			continue
+		}
+		stamp, _, _ = strings.Cut(strings.TrimSuffix(stamp, ext), ".")
+		if !isBackupStamp(stamp) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		out = append(out, backupFile{name: filepath.Join(filepath.Dir(w.cfg.Filename), name), mod: info.ModTime()})
+	}
+	slices.SortFunc(out, func(a, b backupFile) int { return b.mod.Compare(a.mod) })
+	return out, nil
+}
+
+// isBackupStamp 判断 s 是否为备份文件名中的时间，避免误删 app-error.log 这类同前缀的文件
+func isBackupStamp(s string) bool {
+	for _, layout := range []string{dailyLayout, hourlyLayout, sizeLayout} {
+		if _, err := time.Parse(layout, s); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// rotateWriters 未关闭的 RotateWriter，供 ReopenFiles 使用
+//
+// 只登记不持有：写入器在 Close 时移除，New 创建的写入器随 logger 关闭(或被 Init 替换)时释放。
+var (
+	rotateWritersMu sync.Mutex
+	rotateWriters   = map[*RotateWriter]struct{}{}
+)
+
+func registerRotateWriter(w *RotateWriter) {
+	rotateWritersMu.Lock()
+	defer rotateWritersMu.Unlock()
+	rotateWriters[w] = struct{}{}
+}
+
+func unregisterRotateWriter(w *RotateWriter) {
+	rotateWritersMu.Lock()
+	defer rotateWritersMu.Unlock()
+	delete(rotateWriters, w)
+}
+
+// ReopenFiles 对所有未关闭的 RotateWriter 调用 Reopen
+func ReopenFiles() error {
+	rotateWritersMu.Lock()
+	ws := make([]*RotateWriter, 0, len(rotateWriters))
+	for w := range rotateWriters {
+		ws = append(ws, w)
+	}
+	rotateWritersMu.Unlock()
+
+	var errs []error
+	for _, w := range ws {
+		if err := w.Reopen(); err != nil && !errors.Is(err, ErrRotateClosed) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NotifyReopen 收到 sigs 时调用 ReopenFiles，未指定时监听 SIGHUP，返回停止监听的函数
+//
+// 使用外部 logrotate 时，在其 postrotate 中向进程发送 SIGHUP 即可重新打开日志文件：
+//
+//	stop := log.NotifyReopen()
+//	defer stop()
+func NotifyReopen(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = reopenSignals
+	}
+	if len(sigs) == 0 {
+		return func() {}
+	}
+
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+	go func() {
+		for {
+			select {
+			case <-ch:
+				if err := ReopenFiles(); err != nil {
+					fmt.Fprintf(os.Stderr, "log: reopen log files: %v\n", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
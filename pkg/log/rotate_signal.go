@@ -0,0 +1,11 @@
+//go:build unix
+
+package log
+
+import (
+	"os"
+	"syscall"
+)
+
+// reopenSignals NotifyReopen 默认监听的信号
+var reopenSignals = []os.Signal{syscall.SIGHUP}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package log
+
+import "os"
+
+// reopenSignals 当前平台没有 SIGHUP，NotifyReopen 未指定信号时不监听
+var reopenSignals []os.Signal
@@ -0,0 +1,105 @@
+package log
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMaxSizeBytes(t *testing.T) {
+	tests := []struct {
+		mb   int
+		want int64
+	}{
+		{0, defaultMaxSize << 20},
+		{1, 1 << 20},
+		{-1, 0},
+	}
+	for _, tt := range tests {
+		if got := maxSizeBytes(tt.mb); got != tt.want {
+			t.Errorf("maxSizeBytes(%d) = %d, want %d", tt.mb, got, tt.want)
+		}
+	}
+}
+
+func TestRotateWriterSize(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewRotateWriter(RotateConfig{Filename: filepath.Join(dir, "app.log"), MaxSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := append(bytes.Repeat([]byte("x"), 1023), '\n')
+	for range 1025 {
+		if _, err := w.Write(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Fatalf("files = %d, want app.log and one backup", len(entries))
+	}
+	if _, err := w.Write(line); err != ErrRotateClosed {
+		t.Fatalf("Write after Close = %v, want ErrRotateClosed", err)
+	}
+}
+
+func TestRotateWriterUnregisteredOnClose(t *testing.T) {
+	w, err := NewRotateWriter(RotateConfig{Filename: filepath.Join(t.TempDir(), "app.log"), MaxSize: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	registered := func() bool {
+		rotateWritersMu.Lock()
+		defer rotateWritersMu.Unlock()
+		_, ok := rotateWriters[w]
+		return ok
+	}
+	if !registered() {
+		t.Fatal("writer not registered for ReopenFiles")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if registered() {
+		t.Fatal("closed writer still registered")
+	}
+}
+
+func TestRotateWriterSetConfigDuringRotation(t *testing.T) {
+	dir := t.TempDir()
+	cfg := RotateConfig{Filename: filepath.Join(dir, "app.log"), MaxBackups: 2}
+	w, err := NewRotateWriter(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 50 {
+			c := cfg
+			c.Compress, c.MaxBackups = i%2 == 0, i%3+1
+			if err := w.setConfig(c); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	for range 50 {
+		if _, err := w.Write([]byte("line\n")); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-done
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,225 @@
+package log
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// SamplingConfig 采样与限流配置，零值表示不采样也不限流
+//
+// 采样与 zap 的 Sampler 一致：每个 Tick 周期内，相同级别和消息的日志先输出 Initial 条，之后每 Thereafter 条输出一条。
+// 限流按级别和消息计数，每个 RateInterval 周期内最多输出 RateLimit 条。
+// 被采样或限流丢弃的日志按消息汇总，每隔 SummaryInterval 为每条被丢弃的消息输出一条 "suppressed similar log messages"，
+// 汇总为 Warn 级别，只写入级别允许的输出；Sync 与关闭 logger 时立即输出剩余的汇总。
+type SamplingConfig struct {
+	Tick            time.Duration `json:"tick" yaml:"tick" mapstructure:"tick"`                                     // 采样周期，默认 1s
+	Initial         int           `json:"initial" yaml:"initial" mapstructure:"initial"`                            // 每周期先输出的条数，0 表示不采样
+	Thereafter      int           `json:"thereafter" yaml:"thereafter" mapstructure:"thereafter"`                   // 之后每 M 条输出一条，0 表示全部丢弃
+	RateLimit       int           `json:"rate_limit" yaml:"rate_limit" mapstructure:"rate_limit"`                   // 每周期同一消息最多输出的条数，0 表示不限流
+	RateInterval    time.Duration `json:"rate_interval" yaml:"rate_interval" mapstructure:"rate_interval"`          // 限流周期，默认 1s
+	SummaryInterval time.Duration `json:"summary_interval" yaml:"summary_interval" mapstructure:"summary_interval"` // 输出丢弃汇总的间隔，默认 1m
+}
+
+// 采样与限流的默认周期
+const (
+	defaultSampleTick      = time.Second
+	defaultRateInterval    = time.Second
+	defaultSummaryInterval = time.Minute
+)
+
+// enabled 是否开启采样或限流
+func (c SamplingConfig) enabled() bool {
+	return c.Initial > 0 || c.RateLimit > 0
+}
+
+// newSampledCore 按配置为 core 加上采样与限流，返回停止定期汇总的函数
+//
+// 汇总中包含被丢弃日志的消息，redact 不为 nil 时汇总同样经过脱敏后写入。
+func newSampledCore(core zapcore.Core, cfg SamplingConfig, redact *redactor) (zapcore.Core, func() error) {
+	if !cfg.enabled() {
+		return core, nil
+	}
+	out := core
+	if redact != nil {
+		out = &redactCore{Core: core, r: redact}
+	}
+	st := &suppressState{
+		limit:    cfg.RateLimit,
+		interval: orDefault(cfg.RateInterval, defaultRateInterval),
+		summary:  orDefault(cfg.SummaryInterval, defaultSummaryInterval),
+		out:      out,
+		counts:   make(map[suppressKey]int),
+		dropped:  make(map[suppressKey]int),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	now := time.Now()
+	st.windowStart, st.lastSummary = now, now
+
+	sampled := core
+	if cfg.Initial > 0 {
+		sampled = zapcore.NewSamplerWithOptions(core, orDefault(cfg.Tick, defaultSampleTick), cfg.Initial, cfg.Thereafter,
+			zapcore.SamplerHook(func(ent zapcore.Entry, dec zapcore.SamplingDecision) {
+				if dec&zapcore.LogDropped != 0 {
+					st.drop(ent)
+				}
+			}))
+	}
+	go st.run()
+	return &limitCore{Core: sampled, state: st}, st.stop
+}
+
+func orDefault(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}
+
+// limitCore 按消息限流，并定期输出被丢弃日志的汇总
+type limitCore struct {
+	zapcore.Core
+	state *suppressState // With 派生的 core 共享计数
+}
+
+func (c *limitCore) With(fields []zapcore.Field) zapcore.Core {
+	return &limitCore{Core: c.Core.With(fields), state: c.state}
+}
+
+func (c *limitCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Core.Enabled(ent.Level) {
+		return ce
+	}
+	if !c.state.allow(ent) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+func (c *limitCore) Sync() error {
+	c.state.flush(time.Now())
+	return c.Core.Sync()
+}
+
+// suppressKey 限流与汇总按级别和消息区分日志
+type suppressKey struct {
+	level zapcore.Level
+	msg   string
+}
+
+// suppressState 限流计数与被丢弃日志的统计
+type suppressState struct {
+	limit    int
+	interval time.Duration
+	summary  time.Duration
+	out      zapcore.Core // 汇总写入未经采样的 core，经 Check 遵循各输出的级别，配置了脱敏时先经过脱敏
+
+	mu          sync.Mutex
+	windowStart time.Time
+	counts      map[suppressKey]int // 当前限流周期内各消息已输出的条数
+	lastSummary time.Time
+	dropped     map[suppressKey]int // 上次汇总以来各消息被丢弃的条数
+
+	done     chan struct{}
+	stopped  chan struct{}
+	stopping sync.Once
+}
+
+// run 每个汇总间隔检查一次，没有新日志时也能按时输出汇总
+func (s *suppressState) run() {
+	defer close(s.stopped)
+	t := time.NewTicker(s.summary)
+	defer t.Stop()
+	for {
+		select {
+		case now := <-t.C:
+			s.mu.Lock()
+			summaries := s.takeSummaries(now, false)
+			s.mu.Unlock()
+			s.write(summaries)
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// stop 停止定期汇总并输出剩余的汇总
+func (s *suppressState) stop() error {
+	s.stopping.Do(func() { close(s.done) })
+	<-s.stopped
+	s.flush(time.Now())
+	return nil
+}
+
+// allow 判断日志是否未超过限流，同时在到期时输出汇总
+func (s *suppressState) allow(ent zapcore.Entry) bool {
+	s.mu.Lock()
+	now := ent.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if now.Sub(s.windowStart) >= s.interval {
+		s.windowStart = now
+		clear(s.counts)
+	}
+
+	key := suppressKey{level: ent.Level, msg: ent.Message}
+	ok := true
+	if s.limit > 0 {
+		if s.counts[key] >= s.limit {
+			s.dropped[key]++
+			ok = false
+		} else {
+			s.counts[key]++
+		}
+	}
+	summaries := s.takeSummaries(now, false)
+	s.mu.Unlock()
+
+	s.write(summaries)
+	return ok
+}
+
+// drop 记录被采样丢弃的日志
+func (s *suppressState) drop(ent zapcore.Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dropped[suppressKey{level: ent.Level, msg: ent.Message}]++
+}
+
+// flush 立即输出所有未汇总的丢弃统计
+func (s *suppressState) flush(now time.Time) {
+	s.mu.Lock()
+	summaries := s.takeSummaries(now, true)
+	s.mu.Unlock()
+	s.write(summaries)
+}
+
+// takeSummaries 到达汇总间隔(或 force)时取出并清空丢弃统计，调用方需持有锁
+func (s *suppressState) takeSummaries(now time.Time, force bool) map[suppressKey]int {
+	if len(s.dropped) == 0 || (!force && now.Sub(s.lastSummary) < s.summary) {
+		return nil
+	}
+	s.lastSummary = now
+	dropped := s.dropped
+	s.dropped = make(map[suppressKey]int)
+	return dropped
+}
+
+// write 输出丢弃汇总，每条被丢弃的消息一行
+func (s *suppressState) write(summaries map[suppressKey]int) {
+	now := time.Now()
+	for key, n := range summaries {
+		ce := s.out.Check(zapcore.Entry{Level: WarnLevel, Time: now, Message: "suppressed similar log messages"}, nil)
+		if ce == nil {
+			continue
+		}
+		ce.Write(
+			String("suppressed_msg", key.msg),
+			String("suppressed_level", key.level.String()),
+			Int("suppressed", n),
+		)
+	}
+}
@@ -0,0 +1,97 @@
+package log
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSampledCoreSummaries(t *testing.T) {
+	warn, warnLogs := observer.New(WarnLevel)
+	errOnly, errLogs := observer.New(ErrorLevel)
+	core, stop := newSampledCore(zapcore.NewTee(warn, errOnly), SamplingConfig{
+		RateLimit:       1,
+		RateInterval:    time.Hour,
+		SummaryInterval: 20 * time.Millisecond,
+	}, nil)
+	defer stop()
+
+	for range 3 {
+		if ce := core.Check(zapcore.Entry{Level: ErrorLevel, Message: "boom"}, nil); ce != nil {
+			ce.Write()
+		}
+	}
+
+	// 不再写入日志，汇总也应由定时器按时输出
+	deadline := time.Now().Add(2 * time.Second)
+	for warnLogs.FilterMessage("suppressed similar log messages").Len() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("summary not written by the ticker")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	sum := warnLogs.FilterMessage("suppressed similar log messages").All()[0].ContextMap()
+	if sum["suppressed"] != int64(2) || sum["suppressed_msg"] != "boom" {
+		t.Fatalf("summary = %v", sum)
+	}
+	if n := errLogs.FilterMessage("suppressed similar log messages").Len(); n != 0 {
+		t.Fatalf("summary written to an Error-only sink %d times", n)
+	}
+	if n := errLogs.FilterMessage("boom").Len(); n != 1 {
+		t.Fatalf("Error-only sink got %d boom entries, want 1", n)
+	}
+}
+
+func TestSampledCoreStopFlushes(t *testing.T) {
+	obs, logs := observer.New(DebugLevel)
+	core, stop := newSampledCore(obs, SamplingConfig{RateLimit: 1, RateInterval: time.Hour, SummaryInterval: time.Hour}, nil)
+
+	for range 2 {
+		if ce := core.Check(zapcore.Entry{Level: InfoLevel, Message: "tick"}, nil); ce != nil {
+			ce.Write()
+		}
+	}
+	if err := stop(); err != nil {
+		t.Fatal(err)
+	}
+	if n := logs.FilterMessage("suppressed similar log messages").Len(); n != 1 {
+		t.Fatalf("summaries after stop = %d, want 1", n)
+	}
+	if err := stop(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSampledCoreRedactsSummaries(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	l := New(&Config{
+		Level:    "info",
+		Format:   "json",
+		Sinks:    []SinkConfig{{Type: SinkFile, Filename: file}},
+		Sampling: SamplingConfig{RateLimit: 1, RateInterval: time.Hour, SummaryInterval: time.Hour},
+		Redact:   []RedactRule{{Values: []string{RedactEmail}}},
+	})
+	for range 3 {
+		l.Infof("login failed for %s", "alice@example.com")
+	}
+	if err := l.(io.Closer).Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "suppressed similar log messages") {
+		t.Fatalf("no summary written:\n%s", b)
+	}
+	if strings.Contains(string(b), "alice@example.com") {
+		t.Errorf("email written in plain text:\n%s", b)
+	}
+}
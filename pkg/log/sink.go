@@ -0,0 +1,438 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// 输出类型
+const (
+	SinkStdout = "stdout"
+	SinkStderr = "stderr"
+	SinkFile   = "file"
+	SinkSyslog = "syslog"
+	SinkTCP    = "tcp"
+	SinkUDP    = "udp"
+)
+
+// SinkConfig 单个日志输出的配置
+//
+// 每个输出有独立的格式、级别下限与字段过滤，例如只接收 Warn 及以上日志的 error.log：
+//
+//	sinks:
+//	  - type: stdout
+//	  - type: file
+//	    filename: logs/error.log
+//	    level: warn
+type SinkConfig struct {
+	Type    string   `json:"type" yaml:"type" mapstructure:"type"`          // 输出类型: stdout, stderr, file, syslog, tcp, udp
+	Format  string   `json:"format" yaml:"format" mapstructure:"format"`    // 输出格式: json, console，为空时使用 Config.Format
+	Level   string   `json:"level" yaml:"level" mapstructure:"level"`       // 级别下限，为空时与 logger 级别一致
+	Include []string `json:"include" yaml:"include" mapstructure:"include"` // 只输出这些字段，为空表示不限制
+	Exclude []string `json:"exclude" yaml:"exclude" mapstructure:"exclude"` // 不输出这些字段
+
+	Filename   string `json:"filename" yaml:"filename" mapstructure:"filename"`          // file: 日志文件路径
+	MaxSize    int    `json:"max_size" yaml:"max_size" mapstructure:"max_size"`          // file: 单文件最大大小(MB)，为 0 时使用 Config.MaxSize
+	MaxBackups int    `json:"max_backups" yaml:"max_backups" mapstructure:"max_backups"` // file: 最大备份数，为 0 时使用 Config.MaxBackups
+	MaxAge     int    `json:"max_age" yaml:"max_age" mapstructure:"max_age"`             // file: 最大保留天数，为 0 时使用 Config.MaxAge
+	Rotate     string `json:"rotate" yaml:"rotate" mapstructure:"rotate"`                // file: 按时间切割的周期: daily, hourly，为空时使用 Config.Rotate
+
+	Address string `json:"address" yaml:"address" mapstructure:"address"` // syslog: unix socket 路径，为空时使用本机 syslog；tcp/udp: host:port
+	Tag     string `json:"tag" yaml:"tag" mapstructure:"tag"`             // syslog: 日志标签，默认为进程名
+}
+
+// defaultSinks 未配置 Sinks 时的输出：标准输出，以及设置了 Filename 时的日志文件
+func defaultSinks(cfg *Config) []SinkConfig {
+	sinks := []SinkConfig{{Type: SinkStdout}}
+	if cfg.Filename != "" {
+		sinks = append(sinks, SinkConfig{Type: SinkFile, Filename: cfg.Filename})
+	}
+	return sinks
+}
+
+// newSinkCore 创建单个输出的 core，级别同时受 logger 的运行时级别与输出自身的级别下限约束
+//
+// 写入目标按 sinkKey 在 logger 之间共享，返回的 release 释放本 logger 对它的引用，最后一个引用释放时关闭写入目标。
+func newSinkCore(sc SinkConfig, cfg *Config, level zapcore.LevelEnabler) (core zapcore.Core, release func() error, err error) {
+	key := sinkKey(sc)
+	ws, release, err := acquireWriter(key, func() (zapcore.WriteSyncer, func() error, error) {
+		ws, err := newSinkWriter(sc, cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		return ws, closerOf(ws), nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if rw, ok := ws.(*RotateWriter); ok {
+		// 同一文件共享一个 RotateWriter，以最新的配置为准
+		if err := rw.setConfig(rotateConfig(sc, cfg)); err != nil {
+			_ = release()
+			return nil, nil, err
+		}
+	}
+	if cfg.Async.Enabled {
+		inner, innerRelease, created := ws, release, false
+		ws, release, err = acquireWriter(fmt.Sprintf("async:%s:%+v", key, cfg.Async), func() (zapcore.WriteSyncer, func() error, error) {
+			created = true
+			aw := NewAsyncWriter(inner, cfg.Async)
+			// 异步写入器持有对底层写入目标的引用，关闭时一并释放
+			return aw, func() error { return errors.Join(aw.Close(), innerRelease()) }, nil
+		})
+		if !created {
+			_ = innerRelease()
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	format := sc.Format
+	if format == "" {
+		format = cfg.Format
+	}
+	if sc.Level != "" {
+		level = minLevel{LevelEnabler: level, min: parseLevel(sc.Level)}
+	}
+
+	if lw, ok := ws.(levelWriter); ok && sc.Type == SinkSyslog {
+		// syslog 按日志级别设置每条日志的优先级
+		core = &levelCore{LevelEnabler: level, enc: newEncoder(format), out: lw, ws: ws}
+	} else {
+		core = zapcore.NewCore(newEncoder(format), ws, level)
+	}
+	if len(sc.Include) > 0 || len(sc.Exclude) > 0 {
+		core = &fieldFilterCore{Core: core, include: toKeySet(sc.Include), exclude: toKeySet(sc.Exclude)}
+	}
+	return core, release, nil
+}
+
+// sinkKey 返回输出的写入目标，指向同一目标的输出共享写入器，如同一文件只有一个 RotateWriter
+func sinkKey(sc SinkConfig) string {
+	switch sc.Type {
+	case SinkStdout, "":
+		return SinkStdout
+	case SinkFile:
+		if abs, err := filepath.Abs(sc.Filename); err == nil {
+			return SinkFile + ":" + abs
+		}
+		return SinkFile + ":" + sc.Filename
+	case SinkSyslog:
+		return SinkSyslog + ":" + sc.Address + ":" + sc.Tag
+	}
+	return sc.Type + ":" + sc.Address
+}
+
+// sharedWriter 按写入目标共享的写入器
+type sharedWriter struct {
+	ws    zapcore.WriteSyncer
+	close func() error // 最后一个引用释放时调用，可以为 nil
+	refs  int
+}
+
+var (
+	sharedMu      sync.Mutex
+	sharedWriters = map[string]*sharedWriter{}
+)
+
+// acquireWriter 返回 key 对应的共享写入器及释放引用的函数，不存在时调用 open 创建
+func acquireWriter(key string, open func() (zapcore.WriteSyncer, func() error, error)) (zapcore.WriteSyncer, func() error, error) {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	s, ok := sharedWriters[key]
+	if !ok {
+		ws, closeFn, err := open()
+		if err != nil {
+			return nil, nil, err
+		}
+		s = &sharedWriter{ws: ws, close: closeFn}
+		sharedWriters[key] = s
+	}
+	s.refs++
+
+	var once sync.Once
+	release := func() (err error) {
+		once.Do(func() {
+			sharedMu.Lock()
+			s.refs--
+			last := s.refs == 0
+			if last {
+				delete(sharedWriters, key)
+			}
+			sharedMu.Unlock()
+			if last && s.close != nil {
+				err = s.close()
+			}
+		})
+		return err
+	}
+	return s.ws, release, nil
+}
+
+// closerOf 返回写入器的 Close 方法，不需要关闭时返回 nil，标准输出与标准错误不会被关闭
+func closerOf(ws zapcore.WriteSyncer) func() error {
+	if ws == os.Stdout || ws == os.Stderr {
+		return nil
+	}
+	if c, ok := ws.(io.Closer); ok {
+		return c.Close
+	}
+	return nil
+}
+
+// rotateConfig 由输出配置与全局配置得到日志文件的切割配置
+func rotateConfig(sc SinkConfig, cfg *Config) RotateConfig {
+	return RotateConfig{
+		Filename:   sc.Filename,
+		Rotate:     orDefaultString(sc.Rotate, cfg.Rotate),
+		MaxSize:    orDefaultInt(sc.MaxSize, cfg.MaxSize),
+		MaxBackups: orDefaultInt(sc.MaxBackups, cfg.MaxBackups),
+		MaxAge:     orDefaultInt(sc.MaxAge, cfg.MaxAge),
+		Compress:   cfg.Compress,
+	}
+}
+
+// newSinkWriter 按输出类型创建写入目标
+func newSinkWriter(sc SinkConfig, cfg *Config) (zapcore.WriteSyncer, error) {
+	switch sc.Type {
+	case SinkStdout, "":
+		return zapcore.AddSync(os.Stdout), nil
+	case SinkStderr:
+		return zapcore.AddSync(os.Stderr), nil
+	case SinkFile:
+		if sc.Filename == "" {
+			return nil, fmt.Errorf("log: file sink requires filename")
+		}
+		w, err := NewRotateWriter(rotateConfig(sc, cfg))
+		if err != nil {
+			return nil, err
+		}
+		return w, nil
+	case SinkSyslog:
+		return newSyslogWriter(sc)
+	case SinkTCP, SinkUDP:
+		if sc.Address == "" {
+			return nil, fmt.Errorf("log: %s sink requires address", sc.Type)
+		}
+		return &netWriter{network: sc.Type, address: sc.Address}, nil
+	default:
+		return nil, fmt.Errorf("log: unknown sink type %q", sc.Type)
+	}
+}
+
+func orDefaultInt(v, def int) int {
+	if v == 0 {
+		return def
+	}
+	return v
+}
+
+func orDefaultString(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+// minLevel 在原有级别之上增加级别下限
+type minLevel struct {
+	zapcore.LevelEnabler
+	min zapcore.Level
+}
+
+func (m minLevel) Enabled(l zapcore.Level) bool {
+	return l >= m.min && m.LevelEnabler.Enabled(l)
+}
+
+// levelWriter 由按级别写入的输出实现，如 syslog
+type levelWriter interface {
+	writeLevel(level zapcore.Level, p []byte) (int, error)
+}
+
+// levelCore 将编码后的日志连同级别写入 levelWriter
+type levelCore struct {
+	zapcore.LevelEnabler
+	enc zapcore.Encoder
+	out levelWriter
+	ws  zapcore.WriteSyncer
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.enc = c.enc.Clone()
+	for _, f := range fields {
+		f.AddTo(clone.enc)
+	}
+	return &clone
+}
+
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *levelCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	_, err = c.out.writeLevel(ent.Level, buf.Bytes())
+	buf.Free()
+	if err != nil {
+		return err
+	}
+	if ent.Level > zapcore.ErrorLevel {
+		// 与 zapcore.NewCore 一致，进程可能随后退出，先同步输出
+		_ = c.Sync()
+	}
+	return nil
+}
+
+func (c *levelCore) Sync() error {
+	return c.ws.Sync()
+}
+
+// fieldFilterCore 按字段名过滤写入的字段
+type fieldFilterCore struct {
+	zapcore.Core
+	include map[string]bool
+	exclude map[string]bool
+}
+
+func (c *fieldFilterCore) With(fields []zapcore.Field) zapcore.Core {
+	return &fieldFilterCore{Core: c.Core.With(c.filter(fields)), include: c.include, exclude: c.exclude}
+}
+
+func (c *fieldFilterCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *fieldFilterCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, c.filter(fields))
+}
+
+func (c *fieldFilterCore) filter(fields []zapcore.Field) []zapcore.Field {
+	out := make([]zapcore.Field, 0, len(fields))
+	for _, f := range fields {
+		if c.exclude[f.Key] || (len(c.include) > 0 && !c.include[f.Key]) {
+			continue
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+func toKeySet(keys []string) map[string]bool {
+	if len(keys) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[k] = true
+	}
+	return set
+}
+
+// tcp/udp 输出的超时与重连退避
+const (
+	netDialTimeout  = 3 * time.Second
+	netWriteTimeout = 3 * time.Second
+	netMinBackoff   = time.Second
+	netMaxBackoff   = 30 * time.Second
+)
+
+// netWriter 通过 tcp/udp 发送日志，首次写入时建立连接，写入失败后断开并在下次写入时重连
+//
+// 连接失败后按指数退避重连，退避期间以及其他写入方正在建立连接时直接返回错误，
+// 避免对端不可用时每条日志都阻塞在连接超时上，也不拖慢同一 logger 的其他输出。
+type netWriter struct {
+	network string
+	address string
+
+	mu      sync.Mutex
+	conn    net.Conn
+	closed  bool
+	dialing bool
+	backoff time.Duration
+	retryAt time.Time
+}
+
+func (w *netWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, fmt.Errorf("log: %s sink %s closed", w.network, w.address)
+	}
+	if w.conn == nil {
+		if err := w.dial(); err != nil {
+			return 0, err
+		}
+	}
+	_ = w.conn.SetWriteDeadline(time.Now().Add(netWriteTimeout))
+	n, err := w.conn.Write(p)
+	if err != nil {
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+	return n, err
+}
+
+// dial 建立连接，调用方需持有锁，连接期间会暂时释放锁
+func (w *netWriter) dial() error {
+	now := time.Now()
+	if w.dialing || now.Before(w.retryAt) {
+		return fmt.Errorf("log: %s sink %s unavailable, reconnecting", w.network, w.address)
+	}
+
+	w.dialing = true
+	w.mu.Unlock()
+	conn, err := net.DialTimeout(w.network, w.address, netDialTimeout)
+	w.mu.Lock()
+	w.dialing = false
+
+	if err != nil {
+		w.backoff = min(max(w.backoff*2, netMinBackoff), netMaxBackoff)
+		w.retryAt = time.Now().Add(w.backoff)
+		return err
+	}
+	if w.closed {
+		// 连接期间已被 Close，不再保存新建的连接
+		_ = conn.Close()
+		return fmt.Errorf("log: %s sink %s closed", w.network, w.address)
+	}
+	w.conn, w.backoff, w.retryAt = conn, 0, time.Time{}
+	return nil
+}
+
+func (w *netWriter) perMessage() {}
+
+func (w *netWriter) Sync() error {
+	return nil
+}
+
+// Close 关闭连接，之后的写入返回错误，正在建立的连接完成后会被关闭
+func (w *netWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
@@ -0,0 +1,99 @@
+//go:build !windows && !plan9
+
+package log
+
+import (
+	"errors"
+	"log/syslog"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// newSyslogWriter 创建写入 syslog 的输出，首次写入时连接，Write 以 info 优先级写入，logger 按日志级别写入
+func newSyslogWriter(sc SinkConfig) (zapcore.WriteSyncer, error) {
+	return &syslogWriter{address: sc.Address, tag: sc.Tag}, nil
+}
+
+// errSyslogClosed syslog 输出关闭后继续写入时返回
+var errSyslogClosed = errors.New("log: syslog sink closed")
+
+// syslogWriter 通过 unix socket 写入 syslog，写入失败后断开并在下次写入时重连
+type syslogWriter struct {
+	address string
+	tag     string
+
+	mu     sync.Mutex
+	w      *syslog.Writer
+	closed bool
+}
+
+func (s *syslogWriter) Write(p []byte) (int, error) {
+	return s.writeLevel(zapcore.InfoLevel, p)
+}
+
+// writeLevel 实现 levelWriter，按日志级别设置 syslog 优先级
+func (s *syslogWriter) writeLevel(level zapcore.Level, p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0, errSyslogClosed
+	}
+	if s.w == nil {
+		w, err := s.dial()
+		if err != nil {
+			return 0, err
+		}
+		s.w = w
+	}
+	var err error
+	switch m := string(p); {
+	case level >= zapcore.DPanicLevel:
+		err = s.w.Crit(m)
+	case level == zapcore.ErrorLevel:
+		err = s.w.Err(m)
+	case level == zapcore.WarnLevel:
+		err = s.w.Warning(m)
+	case level == zapcore.InfoLevel:
+		err = s.w.Info(m)
+	default:
+		err = s.w.Debug(m)
+	}
+	if err != nil {
+		_ = s.w.Close()
+		s.w = nil
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s *syslogWriter) dial() (*syslog.Writer, error) {
+	const priority = syslog.LOG_INFO | syslog.LOG_USER
+	if s.address == "" {
+		return syslog.New(priority, s.tag)
+	}
+	w, err := syslog.Dial("unixgram", s.address, priority, s.tag)
+	if err != nil {
+		w, err = syslog.Dial("unix", s.address, priority, s.tag)
+	}
+	return w, err
+}
+
+func (s *syslogWriter) perMessage() {}
+
+func (s *syslogWriter) Sync() error {
+	return nil
+}
+
+// Close 关闭与 syslog 的连接，之后的写入返回错误，不再重新连接
+func (s *syslogWriter) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.w == nil {
+		return nil
+	}
+	err := s.w.Close()
+	s.w = nil
+	return err
+}
@@ -0,0 +1,14 @@
+//go:build windows || plan9
+
+package log
+
+import (
+	"fmt"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// newSyslogWriter 当前平台不支持 syslog
+func newSyslogWriter(SinkConfig) (zapcore.WriteSyncer, error) {
+	return nil, fmt.Errorf("log: syslog sink is not supported on this platform")
+}
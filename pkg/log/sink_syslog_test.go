@@ -0,0 +1,53 @@
+//go:build !windows && !plan9
+
+package log
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSyslogWriterNoRedialAfterClose(t *testing.T) {
+	dir, err := os.MkdirTemp("", "syslog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	addr := filepath.Join(dir, "log.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unixgram not available: %v", err)
+	}
+	defer conn.Close()
+
+	ws, err := newSyslogWriter(SinkConfig{Type: SinkSyslog, Address: addr, Tag: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := ws.(*syslogWriter)
+	if _, err := w.writeLevel(ErrorLevel, []byte("boom")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 1024)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// LOG_USER|LOG_ERR = 8|3
+	if got := string(buf[:n]); len(got) < 4 || got[:4] != "<11>" {
+		t.Errorf("message = %q, want priority <11>", got)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("after close")); !errors.Is(err, errSyslogClosed) {
+		t.Errorf("Write after Close = %v, want errSyslogClosed", err)
+	}
+	if w.w != nil {
+		t.Error("Write after Close reopened the connection")
+	}
+}
@@ -0,0 +1,107 @@
+package log
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestNetWriterBackoff(t *testing.T) {
+	// 先占用再释放一个端口，得到一个没有监听的地址
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+
+	w := &netWriter{network: SinkTCP, address: addr}
+	if _, err := w.Write([]byte("x\n")); err == nil {
+		t.Fatal("expected a dial error")
+	}
+
+	start := time.Now()
+	for range 100 {
+		if _, err := w.Write([]byte("x\n")); err == nil {
+			t.Fatal("expected an error while in backoff")
+		}
+	}
+	if d := time.Since(start); d > 100*time.Millisecond {
+		t.Fatalf("writes during backoff took %s, want them to fail fast", d)
+	}
+
+	// 对端恢复后，退避结束时重新连接
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("address reused by another process: %v", err)
+	}
+	defer ln.Close()
+	w.mu.Lock()
+	w.retryAt = time.Time{}
+	w.mu.Unlock()
+	if _, err := w.Write([]byte("x\n")); err != nil {
+		t.Fatalf("write after recovery: %v", err)
+	}
+	_ = w.Close()
+}
+
+// recordLevels 记录每次写入的级别
+type recordLevels struct {
+	mu     sync.Mutex
+	levels []zapcore.Level
+}
+
+func (r *recordLevels) writeLevel(level zapcore.Level, p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.levels = append(r.levels, level)
+	return len(p), nil
+}
+
+func (r *recordLevels) Write(p []byte) (int, error) { return r.writeLevel(InfoLevel, p) }
+func (r *recordLevels) Sync() error                 { return nil }
+func (r *recordLevels) perMessage()                 {}
+
+func TestLevelCoreKeepsLevelThroughAsync(t *testing.T) {
+	out := &recordLevels{}
+	aw := NewAsyncWriter(out, AsyncConfig{})
+	l := NewFromCore(&levelCore{LevelEnabler: DebugLevel, enc: newEncoder("json"), out: aw, ws: aw})
+	l.Debug("d")
+	l.Warn("w")
+	l.Error("e")
+	if err := aw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []zapcore.Level{DebugLevel, WarnLevel, ErrorLevel}
+	if len(out.levels) != len(want) {
+		t.Fatalf("levels = %v, want %v", out.levels, want)
+	}
+	for i := range want {
+		if out.levels[i] != want[i] {
+			t.Fatalf("levels = %v, want %v", out.levels, want)
+		}
+	}
+}
+
+func TestNetWriterCloseDuringDial(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	w := &netWriter{network: SinkTCP, address: ln.Addr().String()}
+	// 模拟 Write 正在连接时调用 Close
+	w.mu.Lock()
+	w.closed = true
+	err = w.dial()
+	conn := w.conn
+	w.mu.Unlock()
+	if err == nil || conn != nil {
+		t.Fatalf("dial after Close = %v, conn = %v, want an error and no connection", err, conn)
+	}
+}
@@ -0,0 +1,376 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// NewSlogHandler 返回写入 l 的 slog.Handler
+//
+// l 由 New 创建时记录直接写入其 zap core，与 l 共享编码器、输出、级别以及 trace 字段与 span 事件；
+// 其他实现的 Logger 按级别调用对应的方法。
+func NewSlogHandler(l Logger) slog.Handler {
+	if zl, ok := l.(*logger); ok {
+		return &slogHandler{core: zl.zap.Core(), span: zl.span}
+	}
+	return &slogHandler{core: loggerCore{l}}
+}
+
+// InitSlog 初始化全局日志，并将 slog 的默认 logger 指向它
+func InitSlog(cfg *Config) Logger {
+	l := Init(cfg)
+	slog.SetDefault(slog.New(NewSlogHandler(l)))
+	return l
+}
+
+// slogHandler 基于 zap core 的 slog.Handler
+//
+// WithGroup 之前的属性直接附加到 core；之后的分组与属性记录在 groups 中，记录时才编码为嵌套对象，
+// 没有属性的分组不会输出，trace 等从 context 取得的字段也不会落入分组。
+type slogHandler struct {
+	core   zapcore.Core
+	span   zapcore.LevelEnabler // 记录为 span 事件的级别，nil 表示不记录
+	groups []slogGroup
+}
+
+// slogGroup WithGroup 打开的分组及之后 WithAttrs 添加的属性
+type slogGroup struct {
+	name   string
+	fields []zapcore.Field
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.core.Enabled(fromSlogLevel(level))
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	// 时间为零值时按 slog 的约定不输出
+	ent := zapcore.Entry{
+		Level:   fromSlogLevel(r.Level),
+		Time:    r.Time,
+		Message: r.Message,
+	}
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		ent.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+	}
+
+	var attrs []zapcore.Field
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = appendAttr(attrs, a)
+		return true
+	})
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		g := h.groups[i]
+		attrs = append(slices.Clip(g.fields), attrs...)
+		if len(attrs) > 0 {
+			attrs = []zapcore.Field{zap.Object(g.name, fieldGroup(attrs))}
+		}
+	}
+
+	core := h.core
+	var fields []zapcore.Field
+	if ctx != nil {
+		if span := trace.SpanFromContext(ctx); h.span != nil && span.IsRecording() {
+			core = wrapRedacted(core, func(c zapcore.Core) zapcore.Core { return withSpan(c, span, h.span) })
+		}
+		fields = extractTraceFields(ctx)
+	}
+	fields = append(fields, attrs...)
+
+	if ce := core.Check(ent, nil); ce != nil {
+		ce.Write(fields...)
+	}
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var fields []zapcore.Field
+	for _, a := range attrs {
+		fields = appendAttr(fields, a)
+	}
+	if len(fields) == 0 {
+		return h
+	}
+	if len(h.groups) == 0 {
+		return &slogHandler{core: h.core.With(fields), span: h.span}
+	}
+	groups := slices.Clone(h.groups)
+	last := &groups[len(groups)-1]
+	last.fields = append(slices.Clip(last.fields), fields...)
+	return &slogHandler{core: h.core, span: h.span, groups: groups}
+}
+
+// WithGroup 之后的属性都嵌套在 name 之下
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	groups := append(slices.Clip(h.groups), slogGroup{name: name})
+	return &slogHandler{core: h.core, span: h.span, groups: groups}
+}
+
+// appendAttr 将 slog 属性转换为 zap 字段，遵循 slog 对空属性与空分组的约定
+func appendAttr(fields []zapcore.Field, a slog.Attr) []zapcore.Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return append(fields, zap.String(a.Key, a.Value.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(a.Key, a.Value.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(a.Key, a.Value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(a.Key, a.Value.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(a.Key, a.Value.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(a.Key, a.Value.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(a.Key, a.Value.Time()))
+	case slog.KindGroup:
+		var group []zapcore.Field
+		for _, ga := range a.Value.Group() {
+			group = appendAttr(group, ga)
+		}
+		if len(group) == 0 {
+			return fields
+		}
+		if a.Key == "" {
+			return append(fields, group...)
+		}
+		return append(fields, zap.Object(a.Key, fieldGroup(group)))
+	default:
+		if err, ok := a.Value.Any().(error); ok {
+			return append(fields, zap.NamedError(a.Key, err))
+		}
+		return append(fields, zap.Any(a.Key, a.Value.Any()))
+	}
+}
+
+// fieldGroup 将 slog 分组中的字段编码为嵌套对象
+type fieldGroup []zapcore.Field
+
+func (g fieldGroup) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, f := range g {
+		f.AddTo(enc)
+	}
+	return nil
+}
+
+// fromSlogLevel 将 slog 级别映射为最接近的 zap 级别
+func fromSlogLevel(l slog.Level) zapcore.Level {
+	switch {
+	case l < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case l < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case l < slog.LevelError:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
+
+// loggerCore 将 zap core 的写入转发给任意 Logger 实现
+type loggerCore struct {
+	l Logger
+}
+
+func (c loggerCore) Enabled(zapcore.Level) bool { return true }
+
+func (c loggerCore) With(fields []zapcore.Field) zapcore.Core {
+	return loggerCore{c.l.With(fields...)}
+}
+
+func (c loggerCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(ent, c)
+}
+
+func (c loggerCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	switch {
+	case ent.Level <= zapcore.DebugLevel:
+		c.l.Debug(ent.Message, fields...)
+	case ent.Level == zapcore.InfoLevel:
+		c.l.Info(ent.Message, fields...)
+	case ent.Level == zapcore.WarnLevel:
+		c.l.Warn(ent.Message, fields...)
+	default:
+		c.l.Error(ent.Message, fields...)
+	}
+	return nil
+}
+
+func (c loggerCore) Sync() error { return c.l.Sync() }
+
+// NewFromSlog 创建写入 slog.Handler 的 Logger
+//
+// 字段按 zap 的编码结果转换为 slog 属性，Fatal 以高于 Error 的级别记录后退出进程。
+func NewFromSlog(h slog.Handler) Logger {
+	return &slogLogger{h: h}
+}
+
+// LevelFatal Fatal 日志在 slog 中使用的级别
+const LevelFatal = slog.LevelError + 4
+
+// slogLogger 基于 slog.Handler 的 Logger
+type slogLogger struct {
+	h    slog.Handler
+	ctx  context.Context // WithContext 传入的 context，记录时传给 Handler
+	name string          // Named 设置的名称，以 logger 属性输出
+}
+
+func (l *slogLogger) Debug(msg string, fields ...Field) { l.log(slog.LevelDebug, msg, toAttrs(fields)) }
+func (l *slogLogger) Info(msg string, fields ...Field)  { l.log(slog.LevelInfo, msg, toAttrs(fields)) }
+func (l *slogLogger) Warn(msg string, fields ...Field)  { l.log(slog.LevelWarn, msg, toAttrs(fields)) }
+func (l *slogLogger) Error(msg string, fields ...Field) { l.log(slog.LevelError, msg, toAttrs(fields)) }
+
+func (l *slogLogger) Fatal(msg string, fields ...Field) {
+	l.log(LevelFatal, msg, toAttrs(fields))
+	os.Exit(1)
+}
+
+func (l *slogLogger) Debugf(template string, args ...any) {
+	l.log(slog.LevelDebug, fmt.Sprintf(template, args...), nil)
+}
+func (l *slogLogger) Infof(template string, args ...any) {
+	l.log(slog.LevelInfo, fmt.Sprintf(template, args...), nil)
+}
+func (l *slogLogger) Warnf(template string, args ...any) {
+	l.log(slog.LevelWarn, fmt.Sprintf(template, args...), nil)
+}
+func (l *slogLogger) Errorf(template string, args ...any) {
+	l.log(slog.LevelError, fmt.Sprintf(template, args...), nil)
+}
+func (l *slogLogger) Fatalf(template string, args ...any) {
+	l.log(LevelFatal, fmt.Sprintf(template, args...), nil)
+	os.Exit(1)
+}
+
+func (l *slogLogger) Debugw(msg string, keysAndValues ...any) {
+	l.log(slog.LevelDebug, msg, kvAttrs(keysAndValues))
+}
+func (l *slogLogger) Infow(msg string, keysAndValues ...any) {
+	l.log(slog.LevelInfo, msg, kvAttrs(keysAndValues))
+}
+func (l *slogLogger) Warnw(msg string, keysAndValues ...any) {
+	l.log(slog.LevelWarn, msg, kvAttrs(keysAndValues))
+}
+func (l *slogLogger) Errorw(msg string, keysAndValues ...any) {
+	l.log(slog.LevelError, msg, kvAttrs(keysAndValues))
+}
+func (l *slogLogger) Fatalw(msg string, keysAndValues ...any) {
+	l.log(LevelFatal, msg, kvAttrs(keysAndValues))
+	os.Exit(1)
+}
+
+func (l *slogLogger) With(fields ...Field) Logger {
+	return &slogLogger{h: l.h.WithAttrs(toAttrs(fields)), ctx: l.ctx, name: l.name}
+}
+
+// WithContext 记录时将 ctx 传给 Handler，并附加 trace 字段及已注册的上下文字段
+func (l *slogLogger) WithContext(ctx context.Context) Logger {
+	if ctx == nil {
+		return l
+	}
+	h := l.h
+	if fields := extractTraceFields(ctx); len(fields) > 0 {
+		h = h.WithAttrs(toAttrs(fields))
+	}
+	return &slogLogger{h: h, ctx: ctx, name: l.name}
+}
+
+// Named 与 zap 一致，名称以 . 连接
+func (l *slogLogger) Named(name string) Logger {
+	if name == "" {
+		return l
+	}
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+	return &slogLogger{h: l.h, ctx: l.ctx, name: name}
+}
+
+func (l *slogLogger) Sync() error { return nil }
+
+// log 记录日志，需由 Debug、Infof 等方法直接调用，以便正确定位调用方
+func (l *slogLogger) log(level slog.Level, msg string, attrs []slog.Attr) {
+	ctx := l.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !l.h.Enabled(ctx, level) {
+		return
+	}
+	// 跳过 runtime.Callers、log 以及 Debug/Info 等方法本身
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	if l.name != "" {
+		r.AddAttrs(slog.String("logger", l.name))
+	}
+	r.AddAttrs(attrs...)
+	_ = l.h.Handle(ctx, r)
+}
+
+// kvAttrs 按 slog 的规则将键值对转换为属性，其中的 Field 会被单独转换，属性顺序与参数一致
+func kvAttrs(keysAndValues []any) []slog.Attr {
+	var (
+		args  []any
+		attrs []slog.Attr
+	)
+	flush := func() {
+		if len(args) == 0 {
+			return
+		}
+		r := slog.NewRecord(time.Time{}, 0, "", 0)
+		r.Add(args...)
+		r.Attrs(func(a slog.Attr) bool {
+			attrs = append(attrs, a)
+			return true
+		})
+		args = args[:0]
+	}
+	for _, kv := range keysAndValues {
+		// 键值对之间的 Field 只能出现在键的位置
+		if f, ok := kv.(Field); ok && len(args)%2 == 0 {
+			flush()
+			attrs = append(attrs, toAttrs([]Field{f})...)
+			continue
+		}
+		args = append(args, kv)
+	}
+	flush()
+	return attrs
+}
+
+// toAttrs 将 zap 字段转换为 slog 属性
+func toAttrs(fields []Field) []slog.Attr {
+	if len(fields) == 0 {
+		return nil
+	}
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	attrs := make([]slog.Attr, 0, len(enc.Fields))
+	for _, f := range fields {
+		if v, ok := enc.Fields[f.Key]; ok {
+			attrs = append(attrs, slog.Any(f.Key, v))
+			delete(enc.Fields, f.Key)
+		}
+	}
+	return attrs
+}
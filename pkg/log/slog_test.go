@@ -0,0 +1,61 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"testing/slogtest"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.uber.org/zap/zapcore"
+)
+
+// newJSONSlogHandler 返回以 JSON 写入 buf 的 slogHandler
+func newJSONSlogHandler(buf *bytes.Buffer) slog.Handler {
+	core := zapcore.NewCore(newEncoder("json"), zapcore.AddSync(buf), DebugLevel)
+	return NewSlogHandler(NewFromCore(core))
+}
+
+func TestSlogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := newJSONSlogHandler(&buf)
+
+	results := func() []map[string]any {
+		var ms []map[string]any
+		for _, line := range bytes.Split(buf.Bytes(), []byte("\n")) {
+			if len(line) == 0 {
+				continue
+			}
+			var m map[string]any
+			if err := json.Unmarshal(line, &m); err != nil {
+				t.Fatal(err)
+			}
+			ms = append(ms, m)
+		}
+		return ms
+	}
+	if err := slogtest.TestHandler(h, results); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSlogHandlerKeepsTraceFieldsOutsideGroups(t *testing.T) {
+	var buf bytes.Buffer
+	ctx, span := sdktrace.NewTracerProvider().Tracer("test").Start(context.Background(), "op")
+	defer span.End()
+
+	slog.New(newJSONSlogHandler(&buf)).WithGroup("G").InfoContext(ctx, "msg", "a", 1)
+
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["trace_id"] != span.SpanContext().TraceID().String() {
+		t.Errorf("trace_id = %v, want it at the top level", m["trace_id"])
+	}
+	if g, _ := m["G"].(map[string]any); len(g) != 1 || g["a"] != float64(1) {
+		t.Errorf("G = %v, want only the record attribute", m["G"])
+	}
+}
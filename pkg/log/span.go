@@ -0,0 +1,124 @@
+package log
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap/zapcore"
+)
+
+// NewSpanCore 包装 core，级别不低于 level 的日志在正常输出之外还会作为事件记录到 span 上
+//
+// 事件名为日志消息，属性包含 log.severity 及日志字段；Error 及以上级别的日志同时将 span 状态设置为 Error。
+// span 未在记录(如未被采样)时不会产生额外开销。
+func NewSpanCore(core zapcore.Core, span trace.Span, level zapcore.LevelEnabler) zapcore.Core {
+	return &spanCore{Core: core, span: span, level: level}
+}
+
+// withSpan 为 core 设置记录事件的 span，core 已是 spanCore 时替换其 span，避免重复调用 WithContext 时每条事件被记录多次
+func withSpan(core zapcore.Core, span trace.Span, level zapcore.LevelEnabler) zapcore.Core {
+	if sc, ok := core.(*spanCore); ok {
+		return &spanCore{Core: sc.Core, span: span, level: level, fields: sc.fields}
+	}
+	return NewSpanCore(core, span, level)
+}
+
+// spanCore 将日志同时写入底层 core 与 span
+type spanCore struct {
+	zapcore.Core
+	span   trace.Span
+	level  zapcore.LevelEnabler
+	fields []zapcore.Field // With 追加的字段，记录到 span 事件中
+}
+
+func (c *spanCore) Enabled(l zapcore.Level) bool {
+	return c.Core.Enabled(l) || c.level.Enabled(l)
+}
+
+func (c *spanCore) With(fields []zapcore.Field) zapcore.Core {
+	return &spanCore{
+		Core:   c.Core.With(fields),
+		span:   c.span,
+		level:  c.level,
+		fields: append(slices.Clip(c.fields), fields...),
+	}
+}
+
+func (c *spanCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	ce = c.Core.Check(ent, ce)
+	if c.level.Enabled(ent.Level) && c.span.IsRecording() {
+		ce = ce.AddCore(ent, spanEventCore{c})
+	}
+	return ce
+}
+
+// spanEventCore 只负责把已通过检查的日志记录为 span 事件，由 spanCore.Check 添加
+type spanEventCore struct {
+	c *spanCore
+}
+
+func (s spanEventCore) Enabled(zapcore.Level) bool        { return true }
+func (s spanEventCore) With([]zapcore.Field) zapcore.Core { return s }
+func (s spanEventCore) Sync() error                       { return nil }
+func (s spanEventCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(ent, s)
+}
+
+func (s spanEventCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range s.c.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	attrs := make([]attribute.KeyValue, 0, len(enc.Fields)+1)
+	attrs = append(attrs, attribute.String("log.severity", ent.Level.String()))
+	for k, v := range enc.Fields {
+		attrs = append(attrs, toAttribute(k, v))
+	}
+	s.c.span.AddEvent(ent.Message, trace.WithTimestamp(ent.Time), trace.WithAttributes(attrs...))
+	if ent.Level >= zapcore.ErrorLevel {
+		s.c.span.SetStatus(codes.Error, ent.Message)
+	}
+	return nil
+}
+
+// toAttribute 将编码后的字段值转换为 span 属性，复杂类型按字符串输出
+func toAttribute(key string, v any) attribute.KeyValue {
+	switch v := v.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case int32:
+		return attribute.Int64(key, int64(v))
+	case uint32:
+		return attribute.Int64(key, int64(v))
+	case uint64:
+		if v <= math.MaxInt64 {
+			return attribute.Int64(key, int64(v))
+		}
+	case float64:
+		return attribute.Float64(key, v)
+	case float32:
+		return attribute.Float64(key, float64(v))
+	case time.Duration:
+		return attribute.String(key, v.String())
+	case time.Time:
+		return attribute.String(key, v.Format(time.RFC3339Nano))
+	case []string:
+		return attribute.StringSlice(key, v)
+	}
+	return attribute.String(key, fmt.Sprint(v))
+}
@@ -0,0 +1,64 @@
+package log
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWithContextRecordsSpanEvents(t *testing.T) {
+	obs, logs := observer.New(DebugLevel)
+	l := NewFromCore(obs).(*logger)
+	l.span = WarnLevel
+
+	rec := tracetest.NewSpanRecorder()
+	ctx, span := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)).Tracer("test").Start(context.Background(), "op")
+	// 重复调用 WithContext 不应重复记录事件
+	cl := l.WithContext(ctx).WithContext(ctx)
+	cl.Info("info")
+	cl.Warn("warn", String("k", "v"))
+	cl.Error("boom")
+	span.End()
+
+	ended := rec.Ended()[0]
+	events := ended.Events()
+	if len(events) != 2 || events[0].Name != "warn" || events[1].Name != "boom" {
+		t.Fatalf("span events = %v, want warn and boom once each", events)
+	}
+	attrs := map[string]string{}
+	for _, a := range events[0].Attributes {
+		attrs[string(a.Key)] = a.Value.Emit()
+	}
+	if attrs["log.severity"] != "warn" || attrs["k"] != "v" {
+		t.Errorf("warn event attributes = %v", attrs)
+	}
+	if ended.Status().Code != codes.Error {
+		t.Errorf("span status = %v, want Error", ended.Status().Code)
+	}
+
+	sc := span.SpanContext()
+	for _, e := range logs.All() {
+		m := e.ContextMap()
+		if m["trace_id"] != sc.TraceID().String() || m["span_id"] != sc.SpanID().String() {
+			t.Errorf("%s: trace fields = %v, %v", e.Message, m["trace_id"], m["span_id"])
+		}
+	}
+}
+
+func TestWithContextSpanEventsDisabled(t *testing.T) {
+	obs, _ := observer.New(DebugLevel)
+	l := NewFromCore(obs)
+
+	rec := tracetest.NewSpanRecorder()
+	ctx, span := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)).Tracer("test").Start(context.Background(), "op")
+	l.WithContext(ctx).Error("boom")
+	span.End()
+
+	if n := len(rec.Ended()[0].Events()); n != 0 {
+		t.Errorf("span events = %d, want 0 when span events are off", n)
+	}
+}
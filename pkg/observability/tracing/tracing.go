@@ -17,12 +17,12 @@ import (
 
 // Config 追踪配置
 type Config struct {
-	ServiceName    string  `json:"service_name" yaml:"service_name"`
-	ServiceVersion string  `json:"service_version" yaml:"service_version"`
-	Environment    string  `json:"environment" yaml:"environment"`
-	Endpoint       string  `json:"endpoint" yaml:"endpoint"` // OTLP collector endpoint
-	SampleRate     float64 `json:"sample_rate" yaml:"sample_rate"`
-	Insecure       bool    `json:"insecure" yaml:"insecure"`
+	ServiceName    string  `json:"service_name" yaml:"service_name" mapstructure:"service_name"`
+	ServiceVersion string  `json:"service_version" yaml:"service_version" mapstructure:"service_version"`
+	Environment    string  `json:"environment" yaml:"environment" mapstructure:"environment"`
+	Endpoint       string  `json:"endpoint" yaml:"endpoint" mapstructure:"endpoint"` // OTLP collector endpoint
+	SampleRate     float64 `json:"sample_rate" yaml:"sample_rate" mapstructure:"sample_rate"`
+	Insecure       bool    `json:"insecure" yaml:"insecure" mapstructure:"insecure"`
 }
 
 // DefaultConfig 默认配置